@@ -76,11 +76,13 @@ func SuggestRollsFromOption(ctx context.Context, option string) {
 		// rank the choices with recents first and with saved rolls after
 		choices = ChoicesFromRollSliceExpression(trunc(RollSliceFromSerials(recents), 5))
 		choices = append(choices, ChoicesFromRollSlice(saved)...)
+		choices = append(choices, customDiceChoices(ctx, u.ID, i.GuildID)...)
 	} else {
 		// pull all recents, add saved expressions, and then sort by similarity
 		// only if there is input to fuzzy-filter with
 		choices = ChoicesFromRollSliceExpression(RollSliceFromSerials(recents))
 		choices = append(choices, ChoicesFromRollSlice(saved)...)
+		choices = append(choices, customDiceChoices(ctx, u.ID, i.GuildID)...)
 		choices = fuzzyFilterOptionChoices(input, choices)
 		// HACK: this is wildly inefficient, but re-allocate/re-size and preface
 		// the option set with the user's current entered text
@@ -87,6 +87,12 @@ func (b *Bot) Setup() {
 		if _, err := b.Cache.Redis.Ping(ctx).Result(); err != nil {
 			logger.Error("failed to connect to redis", zap.Error(err))
 		}
+
+		if b.ReceiptKey == "" {
+			if b.ReceiptKey, err = setupReceiptKey(ctx, b.Cache.Redis); err != nil {
+				logger.Error("failed to set up receipt key", zap.Error(err))
+			}
+		}
 	}
 }
 
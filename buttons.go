@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"strings"
@@ -12,9 +13,46 @@ import (
 var (
 	Dnd5ePadComponents []discordgo.MessageComponent
 	FatePadComponents  []discordgo.MessageComponent
+	FitdPadComponents  []discordgo.MessageComponent
 	D20PadComponents   []discordgo.MessageComponent
 )
 
+// RollMacroInteraction rolls an expression for a message component press and
+// sends the result to the component's channel as a new message. Presses on
+// ephemeral messages are answered ephemerally instead, so secret rolls stay
+// secret.
+func RollMacroInteraction(ctx context.Context, roll string) {
+	s, i, _ := FromContext(ctx)
+	_, response, _ := NewRollMessageResponseFromString(ctx, roll)
+	if response == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+		return
+	}
+	if i.Message != nil && i.Message.Flags&discordgo.MessageFlagsEphemeral != 0 {
+		MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:         response.Content,
+				Embeds:          response.Embeds,
+				Components:      response.Components,
+				Files:           response.Files,
+				AllowedMentions: response.AllowedMentions,
+				Flags:           response.Flags | discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+	_, err := s.ChannelMessageSendComplex(i.ChannelID, response)
+	if err != nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrSendMessagePermissions.Error()))
+	} else {
+		// if we sent correctly, clear the pending button press
+		_ = MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredMessageUpdate,
+		})
+	}
+}
+
 func init() {
 	Dnd5ePadComponents = []discordgo.MessageComponent{
 		discordgo.ActionsRow{
@@ -142,12 +180,12 @@ func init() {
 				discordgo.Button{
 					Label:    "ADV",
 					Style:    discordgo.SuccessButton,
-					CustomID: "macro_2d20k1|d20 (ADV)",
+					CustomID: "macro_" + d20Expression(0, true, false) + "|d20 (ADV)",
 				},
 				discordgo.Button{
 					Label:    "DIS",
 					Style:    discordgo.DangerButton,
-					CustomID: "macro_2d20kl1|d20 (DIS)",
+					CustomID: "macro_" + d20Expression(0, false, true) + "|d20 (DIS)",
 				},
 				discordgo.Button{
 					Label:    "2d20",
@@ -163,6 +201,7 @@ func init() {
 		},
 	}
 	FatePadComponents = makeModifierButtonPad("4dF", -7, 7)
+	FitdPadComponents = makeFitdPadComponents()
 	D20PadComponents = makeModifierButtonPad("d20", -7, 7)
 }
 
@@ -22,12 +22,53 @@ const (
 	KeyCacheGuildNamedSettingFmt     = "cache:guild:%s:%s"
 	KeyCacheInteractionTokenFmt      = "cache:token:%s"
 	KeyCacheUserRecentFmt            = "cache:user:%s:recent"
+	KeyCacheUserHistoryFmt           = "cache:user:%s:history"
 	KeyCacheUserGlobalExpressionsFmt = "cache:user:%s::expressions"
 	KeyCacheUserGuildExpressionsFmt  = "cache:user:%s:%s:expressions"
+	KeyCacheUserDiceFmt              = "cache:user:%s::dice"
+	KeyCacheGuildDiceFmt             = "cache:guild:%s:dice"
+	KeyCacheUserTablesFmt            = "cache:user:%s::tables"
+	KeyCacheGuildTablesFmt           = "cache:guild:%s:tables"
+	KeyCacheUserModifiersFmt         = "cache:user:%s::modifiers"
 
 	KeyStateShardGuildsFmt = "state:shards:%s:guilds"
 )
 
+// Constant keys and fmt string formats for roll counters.
+const (
+	KeyRollsUsers          = "rolls:users"
+	KeyUserRollsTotalFmt   = "rolls:user:%s:total"
+	KeyUserRollsDiceFmt    = "rolls:user:%s:dice"
+	KeyUserRollsStreaksFmt = "rolls:user:%s:streaks"
+	KeyChannelEventsFmt    = "rolls:chan:%s:events"
+)
+
+// Constant fmt string formats for channel state keys.
+const (
+	KeyChannelSessionFmt  = "session:chan:%s"
+	KeyChannelDeckFmt     = "deck:chan:%s"
+	KeyChannelDeckPileFmt = KeyChannelDeckFmt + ":pile"
+
+	KeyChannelInitiativeFmt       = "init:chan:%s"
+	KeyChannelInitiativeStateFmt  = KeyChannelInitiativeFmt + ":state"
+	KeyChannelInitiativeStatusFmt = KeyChannelInitiativeFmt + ":status"
+
+	KeyChannelClocksFmt = "clock:chan:%s"
+
+	KeyContestFmt          = "contest:%s"
+	KeyGroupRollFmt        = "grouproll:%s"
+	KeyGroupRollEntriesFmt = KeyGroupRollFmt + ":rolls"
+	KeyGroupRollDue        = "grouproll:due"
+)
+
+// Constant keys and fmt string formats for scheduled rolls.
+const (
+	KeyScheduleDue      = "schedule:due"
+	KeyScheduleJobFmt   = "schedule:job:%s"
+	KeyScheduleLockFmt  = "schedule:lock:%s:%d"
+	KeyGuildScheduleFmt = "schedule:guild:%s"
+)
+
 // Cache is an in-memory cache with a pass-through to the Redis backend.
 type Cache struct {
 	*lru.Cache[string, any]
@@ -105,10 +146,15 @@ func (c *Cache) HGetAll(ctx context.Context, k string) (hmap map[string]string)
 	if c.Redis == nil {
 		return
 	}
+	var err error
 	func() {
 		defer metrics.MeasureSince([]string{"redis", "hgetall"}, time.Now())
-		hmap = c.Redis.HGetAll(ctx, k).Val()
+		hmap, err = c.Redis.HGetAll(ctx, k).Result()
 	}()
+	// don't cache a failed lookup as an empty hash
+	if err != nil {
+		return
+	}
 	defer c.Add(k, hmap)
 	return
 }
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// WithLegacyResponse returns a child context whose roll responses are always
+// rendered with ResponseTemplate, ex. for responses converted into messages
+// that can't carry Components V2 flags.
+func WithLegacyResponse(ctx context.Context) context.Context {
+	return context.WithValue(ctx, KeyLegacyResponse, true)
+}
+
+// useResultCards returns whether a roll response for a user in a guild should
+// be rendered as a result card. A user's preference overrides the guild's
+// default, and responses outside of guilds always use the legacy renderer.
+func useResultCards(ctx context.Context, u *discordgo.User, guildID string) bool {
+	if legacy, _ := ctx.Value(KeyLegacyResponse).(bool); legacy || guildID == "" || u == nil {
+		return false
+	}
+	switch {
+	case UserHasPreference(u, SettingNoCards):
+		return false
+	case UserHasPreference(u, SettingCards):
+		return true
+	default:
+		return GuildHasSetting(&discordgo.Guild{ID: guildID}, SettingCards)
+	}
+}
+
+// rerollable returns whether the roll of a context can be rolled again from
+// its expression alone. Rolls interpreted by a game system command can't be.
+func rerollable(ctx context.Context) bool {
+	fn, ok := ctx.Value(KeyResultInterpreter).(ResultInterpreter)
+	return !ok || fn == nil
+}
+
+// makeResultCard renders a roll response as a Components V2 container. The
+// detail field, if any, is the breakdown otherwise shown by MessageEmbeds, and
+// image is the name of an attached image of the dice to show as a thumbnail.
+// If again is set the card has a button to roll the expression again.
+func makeResultCard(res *Response, detail *discordgo.MessageEmbedField, image string, again bool) discordgo.Container {
+	var header strings.Builder
+	if res.Name != "" {
+		fmt.Fprintf(&header, "%s rolled ", res.Name)
+	}
+	if res.Expression != "" {
+		fmt.Fprintf(&header, "`%s` ", res.Expression)
+	}
+	if res.Label != "" {
+		fmt.Fprintf(&header, "_%s_", res.Label)
+	}
+
+	var result strings.Builder
+	if header.Len() > 0 {
+		result.WriteString(strings.TrimSpace(header.String()) + "\n")
+	}
+	fmt.Fprintf(&result, "## %s\n`%s`", res.Result, res.Rolled)
+
+	texts := []discordgo.MessageComponent{
+		discordgo.TextDisplay{Content: result.String()},
+	}
+	if res.Outcome != "" {
+		texts = append(texts, discordgo.TextDisplay{Content: fmt.Sprintf("**%s**", res.Outcome)})
+	}
+
+	var components []discordgo.MessageComponent
+	if image != "" {
+		components = append(components, discordgo.Section{
+			Components: texts,
+			Accessory: discordgo.Thumbnail{
+				Media: discordgo.UnfurledMediaItem{URL: "attachment://" + image},
+			},
+		})
+	} else {
+		components = append(components, texts...)
+	}
+
+	if detail != nil {
+		components = append(components,
+			discordgo.Separator{},
+			discordgo.TextDisplay{Content: fmt.Sprintf("**%s**\n%s", detail.Name, detail.Value)},
+		)
+	}
+
+	var buttons []discordgo.MessageComponent
+	roll := (&NamedRollInput{Expression: res.Expression, Label: res.Label}).RollableString()
+	if again && res.Expression != "" && len("macro_"+roll) <= 100 {
+		buttons = append(buttons, discordgo.Button{
+			Label:    "Roll again",
+			Style:    discordgo.PrimaryButton,
+			CustomID: "macro_" + roll,
+		})
+	}
+	if res.Receipt != "" {
+		components = append(components, discordgo.TextDisplay{Content: fmt.Sprintf("-# Receipt `%s`", res.Receipt)})
+		buttons = append(buttons, discordgo.Button{
+			Label:    "Verify",
+			Style:    discordgo.SecondaryButton,
+			CustomID: newComponentID("verify", res.Receipt),
+		})
+	}
+	if len(buttons) > 0 {
+		components = append(components,
+			discordgo.Separator{},
+			discordgo.ActionsRow{Components: buttons},
+		)
+	}
+
+	return discordgo.Container{Components: components}
+}
+
+// resultCardDetail returns the breakdown of a roll to show on its result card,
+// or nil if a detailed result wasn't requested.
+func resultCardDetail(res *Response, detailed bool) *discordgo.MessageEmbedField {
+	if !detailed {
+		return nil
+	}
+	return embedField(res)
+}
+
+// resultCardImage returns the name of an attached dice image to show on a
+// result card, or an empty string if there's none.
+func resultCardImage(file *discordgo.File) string {
+	if file == nil {
+		return ""
+	}
+	return file.Name
+}
+
+// appendResponseText adds a line of text to a roll's interaction response,
+// after the result of a result card or at the end of legacy content.
+func appendResponseText(response *discordgo.InteractionResponse, text string) {
+	if response.Data.Flags&discordgo.MessageFlagsIsComponentsV2 == 0 {
+		response.Data.Content += "\n" + text
+		return
+	}
+	card, ok := response.Data.Components[0].(discordgo.Container)
+	if !ok {
+		return
+	}
+	// keep the card's buttons last
+	n := len(card.Components)
+	for ; n > 0; n-- {
+		switch card.Components[n-1].(type) {
+		case discordgo.ActionsRow, discordgo.Separator:
+			continue
+		}
+		break
+	}
+	card.Components = slices.Insert(card.Components, n, discordgo.MessageComponent(discordgo.TextDisplay{Content: text}))
+	response.Data.Components[0] = card
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// cardButtons returns the custom IDs of the buttons on a result card.
+func cardButtons(card discordgo.Container) []string {
+	var ids []string
+	for _, c := range card.Components {
+		if row, ok := c.(discordgo.ActionsRow); ok {
+			for _, b := range row.Components {
+				ids = append(ids, b.(discordgo.Button).CustomID)
+			}
+		}
+	}
+	return ids
+}
+
+func Test_makeResultCard(t *testing.T) {
+	tests := []struct {
+		name        string
+		res         *Response
+		detail      *discordgo.MessageEmbedField
+		image       string
+		wantFirst   string
+		wantSection bool
+		wantButtons []string
+		again       bool
+		wantLen     int
+	}{
+		{
+			name:        "basic",
+			res:         &Response{Name: "<@1>", Expression: "1d20+2", Label: "Stealth", Rolled: "(14)+2", Result: "16"},
+			wantFirst:   "<@1> rolled `1d20+2` _Stealth_\n## 16\n`(14)+2`",
+			again:       true,
+			wantButtons: []string{"macro_1d20+2 # Stealth"},
+			wantLen:     3,
+		},
+		{
+			name:        "outcome and receipt",
+			res:         &Response{Expression: "2d6+1", Rolled: "(4+5)+1", Result: "10", Outcome: "Strong hit", Receipt: "abc"},
+			wantFirst:   "`2d6+1`\n## 10\n`(4+5)+1`",
+			again:       true,
+			wantButtons: []string{"macro_2d6+1", "verify:abc"},
+			wantLen:     5,
+		},
+		{
+			name:        "interpreted",
+			res:         &Response{Expression: "2d6+1", Rolled: "(4+5)+1", Result: "10", Outcome: "Strong hit", Receipt: "abc"},
+			wantButtons: []string{"verify:abc"},
+			wantLen:     5,
+		},
+		{
+			name:        "detailed with image",
+			res:         &Response{Expression: "d6", Rolled: "(3)", Result: "3"},
+			detail:      &discordgo.MessageEmbedField{Name: "d6 ⇒ 3", Value: "3"},
+			image:       "dice.png",
+			again:       true,
+			wantSection: true,
+			wantButtons: []string{"macro_d6"},
+			wantLen:     5,
+		},
+		{
+			name:    "no expression",
+			res:     &Response{Rolled: "3", Result: "3"},
+			wantLen: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := makeResultCard(tt.res, tt.detail, tt.image, tt.again)
+			if len(card.Components) != tt.wantLen {
+				t.Fatalf("makeResultCard() has %d components, want %d", len(card.Components), tt.wantLen)
+			}
+			section, isSection := card.Components[0].(discordgo.Section)
+			if isSection != tt.wantSection {
+				t.Fatalf("makeResultCard() section = %v, want %v", isSection, tt.wantSection)
+			}
+			if isSection {
+				if url := section.Accessory.(discordgo.Thumbnail).Media.URL; url != "attachment://"+tt.image {
+					t.Errorf("makeResultCard() thumbnail = %v", url)
+				}
+			} else if tt.wantFirst != "" {
+				if got := card.Components[0].(discordgo.TextDisplay).Content; got != tt.wantFirst {
+					t.Errorf("makeResultCard() text = %q, want %q", got, tt.wantFirst)
+				}
+			}
+			got := cardButtons(card)
+			if len(got) != len(tt.wantButtons) {
+				t.Fatalf("makeResultCard() buttons = %v, want %v", got, tt.wantButtons)
+			}
+			for n := range got {
+				if got[n] != tt.wantButtons[n] {
+					t.Errorf("makeResultCard() buttons = %v, want %v", got, tt.wantButtons)
+				}
+			}
+		})
+	}
+}
+
+func Test_rerollable(t *testing.T) {
+	ctx := context.Background()
+	if !rerollable(ctx) {
+		t.Errorf("rerollable() of a plain roll = false, want true")
+	}
+	if !rerollable(WithResultInterpreter(ctx, nil)) {
+		t.Errorf("rerollable() without an interpreter = false, want true")
+	}
+	if rerollable(WithResultInterpreter(ctx, fateLadderInterpreter)) {
+		t.Errorf("rerollable() of an interpreted roll = true, want false")
+	}
+}
+
+func Test_appendResponseText(t *testing.T) {
+	legacy := &discordgo.InteractionResponse{Data: &discordgo.InteractionResponseData{Content: "hit"}}
+	appendResponseText(legacy, "damage")
+	if legacy.Data.Content != "hit\ndamage" {
+		t.Errorf("appendResponseText() content = %q", legacy.Data.Content)
+	}
+
+	card := makeResultCard(&Response{Expression: "1d20", Rolled: "(15)", Result: "15"}, nil, "", true)
+	response := &discordgo.InteractionResponse{Data: &discordgo.InteractionResponseData{
+		Flags:      discordgo.MessageFlagsIsComponentsV2,
+		Components: []discordgo.MessageComponent{card},
+	}}
+	appendResponseText(response, "damage")
+	got := response.Data.Components[0].(discordgo.Container).Components
+	if len(got) != 4 {
+		t.Fatalf("appendResponseText() has %d components, want 4", len(got))
+	}
+	if text, ok := got[1].(discordgo.TextDisplay); !ok || text.Content != "damage" {
+		t.Errorf("appendResponseText() added %v, want damage before the buttons", got[1])
+	}
+	if _, ok := got[3].(discordgo.ActionsRow); !ok {
+		t.Errorf("appendResponseText() moved the buttons: %v", got[3])
+	}
+}
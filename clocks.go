@@ -0,0 +1,428 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Limits of progress clocks.
+const (
+	minClockSegments = 2
+	maxClockSegments = 12
+	maxClocks        = 25
+)
+
+// Errors for progress clocks.
+var (
+	ErrInvalidTickCondition = errors.New("Tick conditions need a roll compared to a number, like `1d6<=2` or `1d20>=15`.")
+	ErrTooManyClocks        = fmt.Errorf("Channels can only have up to %d clocks.", maxClocks)
+)
+
+// tickConditionRegexp matches a clock's tick condition, ex. "1d6<=2". The
+// comparison is split off the end so it isn't read as a roll's target.
+var tickConditionRegexp = regexp.MustCompile(`^(.*[^<>=\s])\s*(<=|>=|<|>|=)\s*(-?\d+)$`)
+
+// A Clock is a progress clock with a number of segments that get filled in as
+// it ticks. Clocks with a tick condition can be ticked by a roll.
+type Clock struct {
+	Name     string `json:"name"`
+	Segments int    `json:"segments"`
+	Filled   int    `json:"filled"`
+	TickOn   string `json:"tick_on,omitempty"`
+}
+
+// Tick fills in or clears segments of the clock, returning whether it's full.
+func (c *Clock) Tick(amount int) bool {
+	c.Filled = min(max(c.Filled+amount, 0), c.Segments)
+	return c.Full()
+}
+
+// Full returns whether every segment of the clock is filled.
+func (c *Clock) Full() bool {
+	return c.Filled >= c.Segments
+}
+
+// String renders the clock's segments, ex. "🟥🟥⬛⬛⬛⬛ 2/6".
+func (c *Clock) String() string {
+	return fmt.Sprintf("%s%s %d/%d",
+		strings.Repeat("🟥", c.Filled), strings.Repeat("⬛", c.Segments-c.Filled), c.Filled, c.Segments)
+}
+
+// A TickCondition is a roll compared to a target that ticks a clock when met.
+type TickCondition struct {
+	Expression string
+	Op         string
+	Target     float64
+}
+
+// parseTickCondition parses a clock's tick condition, ex. "1d6<=2".
+func parseTickCondition(s string) (*TickCondition, error) {
+	match := tickConditionRegexp.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return nil, ErrInvalidTickCondition
+	}
+	target, err := strconv.ParseFloat(match[3], 64)
+	if err != nil {
+		return nil, ErrInvalidTickCondition
+	}
+	return &TickCondition{Expression: match[1], Op: match[2], Target: target}, nil
+}
+
+// Met returns whether a roll's result meets the condition.
+func (tc *TickCondition) Met(result float64) bool {
+	switch tc.Op {
+	case "<=":
+		return result <= tc.Target
+	case ">=":
+		return result >= tc.Target
+	case "<":
+		return result < tc.Target
+	case ">":
+		return result > tc.Target
+	default:
+		return result == tc.Target
+	}
+}
+
+// GetClocks returns the clocks of a channel, by lowercase name.
+func GetClocks(ctx context.Context, cid string) (map[string]*Clock, error) {
+	data, err := DiceGolem.Cache.Redis.HGetAll(ctx, fmt.Sprintf(KeyChannelClocksFmt, cid)).Result()
+	if err != nil {
+		return nil, err
+	}
+	clocks := make(map[string]*Clock, len(data))
+	for name, v := range data {
+		clock := new(Clock)
+		if err := json.Unmarshal([]byte(v), clock); err == nil {
+			clocks[name] = clock
+		}
+	}
+	return clocks, nil
+}
+
+// GetClock returns a clock of a channel.
+func GetClock(ctx context.Context, cid, name string) (*Clock, error) {
+	data, err := DiceGolem.Cache.Redis.HGet(ctx, fmt.Sprintf(KeyChannelClocksFmt, cid), strings.ToLower(name)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	clock := new(Clock)
+	return clock, json.Unmarshal(data, clock)
+}
+
+// SetClock saves a clock of a channel.
+func SetClock(ctx context.Context, cid string, clock *Clock) error {
+	data, err := json.Marshal(clock)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf(KeyChannelClocksFmt, cid)
+	_, err = DiceGolem.Cache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, strings.ToLower(clock.Name), string(data))
+		pipe.Expire(ctx, key, DiceGolem.DataTTL)
+		return nil
+	})
+	return err
+}
+
+// DeleteClock deletes a clock of a channel, returning whether it existed.
+func DeleteClock(ctx context.Context, cid, name string) (bool, error) {
+	n, err := DiceGolem.Cache.Redis.HDel(ctx, fmt.Sprintf(KeyChannelClocksFmt, cid), strings.ToLower(name)).Result()
+	return n > 0, err
+}
+
+// makeClockEmbed renders a clock as an embed.
+func makeClockEmbed(clock *Clock) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       "🕒 " + clock.Name,
+		Description: clock.String(),
+	}
+	if clock.TickOn != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Ticks on " + clock.TickOn}
+	}
+	if clock.Full() {
+		embed.Description += "\n**The clock is full!**"
+		embed.Color = 0xdd2e44
+	}
+	return embed
+}
+
+// makeClockComponents creates the buttons of a clock.
+func makeClockComponents(clock *Clock) []discordgo.MessageComponent {
+	buttons := []discordgo.MessageComponent{
+		discordgo.Button{
+			Label:    "−",
+			Style:    discordgo.SecondaryButton,
+			CustomID: newComponentID("clock", "tick", clock.Name, "-1"),
+			Disabled: clock.Filled == 0,
+		},
+		discordgo.Button{
+			Label:    "+",
+			Style:    discordgo.PrimaryButton,
+			CustomID: newComponentID("clock", "tick", clock.Name, "1"),
+			Disabled: clock.Full(),
+		},
+	}
+	if clock.TickOn != "" {
+		buttons = append(buttons, discordgo.Button{
+			Label:    truncString("Roll "+clock.TickOn, 80),
+			Style:    discordgo.SecondaryButton,
+			Emoji:    &discordgo.ComponentEmoji{Name: "🎲"},
+			CustomID: newComponentID("clock", "roll", clock.Name),
+			Disabled: clock.Full(),
+		})
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: buttons},
+	}
+}
+
+// newClockResponse creates a message showing a clock, with an optional note of
+// what happened to it.
+func newClockResponse(clock *Clock, note string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    note,
+			Embeds:     []*discordgo.MessageEmbed{makeClockEmbed(clock)},
+			Components: makeClockComponents(clock),
+		},
+	}
+}
+
+// rollClockTick rolls a clock's tick condition and ticks the clock if it's
+// met, returning a note of the roll.
+func rollClockTick(ctx context.Context, clock *Clock) (string, error) {
+	condition, err := parseTickCondition(clock.TickOn)
+	if err != nil {
+		return "", err
+	}
+	res, _, err := evaluateRoll(ctx, condition.Expression)
+	if err == nil && res == nil {
+		err = ErrNilExpressionResult
+	}
+	if err != nil {
+		return "", err
+	}
+	note := fmt.Sprintf("Rolled `%s`: `%s` = **%v**", condition.Expression, res.Rolled, res.Result)
+	if condition.Met(res.Result) {
+		clock.Tick(1)
+		return note + " and ticked the clock!", nil
+	}
+	return note + ". No tick.", nil
+}
+
+// clockNotFound responds that a channel has no clock of a name.
+func clockNotFound(ctx context.Context, name string, err error) {
+	s, i, _ := FromContext(ctx)
+	if !errors.Is(err, redis.Nil) {
+		logger.Error("error getting clock", zap.Error(err))
+	}
+	MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+		fmt.Sprintf("There is no clock named `%s` in this channel. Use %s to create one.", name, CommandMention("clock", "create"))))
+}
+
+// InteractionClock manages a channel's progress clocks.
+func InteractionClock(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "clock"}, 1)
+
+	if DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	subcommand := options[0].Name
+	options = options[0].Options
+
+	if subcommand == "list" {
+		clocks, err := GetClocks(ctx, i.ChannelID)
+		if err != nil {
+			logger.Error("error getting clocks", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if len(clocks) == 0 {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+				fmt.Sprintf("There are no clocks in this channel. Use %s to create one.", CommandMention("clock", "create"))))
+			return
+		}
+		var b strings.Builder
+		for _, name := range slices.Sorted(maps.Keys(clocks)) {
+			fmt.Fprintf(&b, "**%s** %s\n", clocks[name].Name, clocks[name])
+		}
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(b.String()))
+		return
+	}
+
+	name := strings.TrimSpace(mustGetOptionByName(options, "name").StringValue())
+	if subcommand == "create" {
+		if name == "" || strings.Contains(name, ":") {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Clock names can't be empty or contain `:`."))
+			return
+		}
+		clock := &Clock{
+			Name:     name,
+			Segments: int(mustGetOptionByName(options, "segments").IntValue()),
+		}
+		if opt := getOptionByName(options, "tick-on"); opt != nil {
+			condition, err := parseTickCondition(opt.StringValue())
+			if err != nil {
+				MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(err.Error()))
+				return
+			}
+			if _, _, err := evaluateRoll(ctx, condition.Expression); err != nil {
+				MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(createFriendlyError(err).Error()))
+				return
+			}
+			clock.TickOn = strings.TrimSpace(opt.StringValue())
+		}
+		clocks, err := GetClocks(ctx, i.ChannelID)
+		if err != nil {
+			logger.Error("error getting clocks", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if _, ok := clocks[strings.ToLower(name)]; !ok && len(clocks) >= maxClocks {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrTooManyClocks.Error()))
+			return
+		}
+		if err := SetClock(ctx, i.ChannelID, clock); err != nil {
+			logger.Error("error saving clock", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, newClockResponse(clock, "")); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+		return
+	}
+
+	clock, err := GetClock(ctx, i.ChannelID, name)
+	if err != nil {
+		clockNotFound(ctx, name, err)
+		return
+	}
+	var note string
+	switch subcommand {
+	case "show":
+	case "tick":
+		amount := 1
+		if opt := getOptionByName(options, "amount"); opt != nil {
+			amount = int(opt.IntValue())
+		}
+		clock.Tick(amount)
+	case "roll":
+		if clock.TickOn == "" {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+				fmt.Sprintf("`%s` doesn't have a tick condition to roll.", clock.Name)))
+			return
+		}
+		if note, err = rollClockTick(ctx, clock); err != nil {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(createFriendlyError(err).Error()))
+			return
+		}
+	case "delete":
+		if _, err := DeleteClock(ctx, i.ChannelID, name); err != nil {
+			logger.Error("error deleting clock", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(fmt.Sprintf("Deleted the `%s` clock.", clock.Name)))
+		return
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry, an invalid subcommand was received!"))
+		return
+	}
+	if subcommand != "show" {
+		if err := SetClock(ctx, i.ChannelID, clock); err != nil {
+			logger.Error("error saving clock", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, newClockResponse(clock, note)); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
+
+// InteractionClockComponent handles the buttons of a clock, updating its
+// message in place.
+func InteractionClockComponent(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "clock"}, 1)
+	_, args := parseComponentID(i.MessageComponentData().CustomID)
+	if len(args) < 2 || DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+		return
+	}
+	clock, err := GetClock(ctx, i.ChannelID, args[1])
+	if err != nil {
+		clockNotFound(ctx, args[1], err)
+		return
+	}
+
+	var note string
+	switch args[0] {
+	case "tick":
+		amount := 1
+		if len(args) > 2 {
+			amount, _ = strconv.Atoi(args[2])
+		}
+		clock.Tick(amount)
+	case "roll":
+		if note, err = rollClockTick(ctx, clock); err != nil {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(createFriendlyError(err).Error()))
+			return
+		}
+		note = UserFromInteraction(i).Mention() + " " + strings.ToLower(note[:1]) + note[1:]
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+		return
+	}
+	if err := SetClock(ctx, i.ChannelID, clock); err != nil {
+		logger.Error("error saving clock", zap.Error(err))
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+	response := newClockResponse(clock, note)
+	response.Type = discordgo.InteractionResponseUpdateMessage
+	response.Data.AllowedMentions = &discordgo.MessageAllowedMentions{Users: []string{}}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, response); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
+
+// SuggestClocks suggests the names of the channel's clocks.
+func SuggestClocks(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	var names []string
+	if DiceGolem.Cache.Redis != nil {
+		if clocks, err := GetClocks(ctx, i.ChannelID); err == nil {
+			for _, clock := range clocks {
+				names = append(names, clock.Name)
+			}
+		}
+	}
+	slices.Sort(names)
+	choices := ChoicesFromStrings(names)
+	if input := getOptionByName(i.ApplicationCommandData().Options, "name").StringValue(); input != "" {
+		choices = fuzzyFilterOptionChoices(input, choices)
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, newChoicesResponse(trunc(choices, 25))); err != nil {
+		logger.Error("autocomplete", zap.Error(err))
+	}
+}
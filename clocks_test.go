@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClock_Tick(t *testing.T) {
+	tests := []struct {
+		name     string
+		filled   int
+		amount   int
+		want     int
+		wantFull bool
+	}{
+		{name: "tick", filled: 2, amount: 1, want: 3},
+		{name: "fill", filled: 5, amount: 1, want: 6, wantFull: true},
+		{name: "overfill", filled: 5, amount: 3, want: 6, wantFull: true},
+		{name: "clear", filled: 2, amount: -1, want: 1},
+		{name: "overclear", filled: 2, amount: -5, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &Clock{Name: "Alarm", Segments: 6, Filled: tt.filled}
+			if full := clock.Tick(tt.amount); full != tt.wantFull {
+				t.Errorf("Clock.Tick() full = %v, want %v", full, tt.wantFull)
+			}
+			if clock.Filled != tt.want {
+				t.Errorf("Clock.Tick() filled = %v, want %v", clock.Filled, tt.want)
+			}
+		})
+	}
+}
+
+func TestClock_String(t *testing.T) {
+	clock := &Clock{Name: "Alarm", Segments: 4, Filled: 1}
+	if got, want := clock.String(), "🟥⬛⬛⬛ 1/4"; got != want {
+		t.Errorf("Clock.String() = %v, want %v", got, want)
+	}
+}
+
+func Test_parseTickCondition(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    *TickCondition
+		wantErr error
+	}{
+		{input: "1d6<=2", want: &TickCondition{Expression: "1d6", Op: "<=", Target: 2}},
+		{input: "1d20 >= 15", want: &TickCondition{Expression: "1d20", Op: ">=", Target: 15}},
+		{input: "2d6+1>8", want: &TickCondition{Expression: "2d6+1", Op: ">", Target: 8}},
+		{input: "1d4=1", want: &TickCondition{Expression: "1d4", Op: "=", Target: 1}},
+		{input: "1d6", wantErr: ErrInvalidTickCondition},
+		{input: "<=2", wantErr: ErrInvalidTickCondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseTickCondition(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseTickCondition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if *got != *tt.want {
+				t.Errorf("parseTickCondition() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTickCondition_Met(t *testing.T) {
+	tests := []struct {
+		op     string
+		result float64
+		want   bool
+	}{
+		{op: "<=", result: 2, want: true},
+		{op: "<=", result: 3, want: false},
+		{op: "<", result: 2, want: false},
+		{op: ">=", result: 2, want: true},
+		{op: ">", result: 3, want: true},
+		{op: "=", result: 2, want: true},
+		{op: "=", result: 1, want: false},
+	}
+	for _, tt := range tests {
+		tc := &TickCondition{Expression: "1d6", Op: tt.op, Target: 2}
+		if got := tc.Met(tt.result); got != tt.want {
+			t.Errorf("TickCondition{%s 2}.Met(%v) = %v, want %v", tt.op, tt.result, got, tt.want)
+		}
+	}
+}
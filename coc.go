@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// Call of Cthulhu 7e success levels.
+const (
+	CocCritical = "Critical success"
+	CocExtreme  = "Extreme success"
+	CocHard     = "Hard success"
+	CocRegular  = "Regular success"
+	CocFailure  = "Failure"
+	CocFumble   = "Fumble"
+)
+
+// percentileDigit returns the digit a d10 represents on a percentile roll,
+// where a 10 is read as 0.
+func percentileDigit(d *dice.Die) int {
+	return int(d.Result.Value) % 10
+}
+
+// percentileValue combines tens and units digits into a percentile result,
+// where 00 and 0 are read as 100.
+func percentileValue(tens, units int) int {
+	if v := tens*10 + units; v != 0 {
+		return v
+	}
+	return 100
+}
+
+// rollPercentile rolls a percentile die with bonus ("d100b1") or penalty
+// ("d100p1") dice. An additional tens die is rolled for each bonus or penalty
+// die, and the lowest or highest result is kept respectively.
+func rollPercentile(ctx context.Context, match []string) (*RolledNotation, error) {
+	penalty := strings.EqualFold(match[1], "p")
+	extra, _ := strconv.Atoi(match[2])
+
+	tens, err := rollDice(ctx, 1+extra, 10, ExplodeNone)
+	if err != nil {
+		return nil, err
+	}
+	units := &dice.Die{Size: 10}
+	if err := units.Roll(ctx); err != nil {
+		return nil, err
+	}
+	group := &dice.RollerGroup{}
+	for _, roller := range tens.Group {
+		addRoller(group, roller)
+	}
+	addRoller(group, units)
+
+	var chosen *dice.Die
+	value := 0
+	for _, roller := range tens.Group {
+		die := roller.(*dice.Die)
+		v := percentileValue(percentileDigit(die), percentileDigit(units))
+		if chosen == nil || (penalty && v > value) || (!penalty && v < value) {
+			chosen, value = die, v
+		}
+	}
+
+	n := &RolledNotation{
+		Group: group,
+		Value: float64(value),
+	}
+	n.markdown = func(context.Context) string {
+		parts := make([]string, len(tens.Group))
+		for i, roller := range tens.Group {
+			parts[i] = fmt.Sprintf("%02d", percentileDigit(roller.(*dice.Die))*10)
+			if roller == chosen {
+				parts[i] = "**" + parts[i] + "**"
+			}
+		}
+		return fmt.Sprintf("[%s] + [%d]", strings.Join(parts, ", "), percentileDigit(units))
+	}
+	return n, nil
+}
+
+// cocSuccessLevel determines the success level of a percentile roll against
+// a skill. Fumbles are rolls of 100, or 96+ for skills below 50.
+func cocSuccessLevel(roll, skill int) string {
+	switch {
+	case roll == 1:
+		return CocCritical
+	case roll == 100 || (skill < 50 && roll >= 96):
+		return CocFumble
+	case roll <= skill/5:
+		return CocExtreme
+	case roll <= skill/2:
+		return CocHard
+	case roll <= skill:
+		return CocRegular
+	default:
+		return CocFailure
+	}
+}
+
+// cocExpression builds the expression for a skill roll. Bonus and penalty
+// dice cancel each other out.
+func cocExpression(bonus, penalty int, label string) string {
+	expression := "d100"
+	switch net := bonus - penalty; {
+	case net > 0:
+		expression += fmt.Sprintf("b%d", net)
+	case net < 0:
+		expression += fmt.Sprintf("p%d", -net)
+	}
+	if label != "" {
+		expression += " # " + label
+	}
+	return expression
+}
+
+// InteractionCoc rolls a Call of Cthulhu skill roll.
+func InteractionCoc(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "coc"}, 1)
+
+	options := i.ApplicationCommandData().Options
+	skill := int(mustGetOptionByName(options, "skill").IntValue())
+	var (
+		bonus, penalty int
+		label          string
+	)
+	if opt := getOptionByName(options, "bonus"); opt != nil {
+		bonus = int(opt.IntValue())
+	}
+	if opt := getOptionByName(options, "penalty"); opt != nil {
+		penalty = int(opt.IntValue())
+	}
+	if opt := getOptionByName(options, "label"); opt != nil {
+		label = opt.StringValue()
+	}
+
+	interpret := func(res *Response) string {
+		return fmt.Sprintf("%s (%d)", cocSuccessLevel(int(res.ExpressionResult.Result), skill), skill)
+	}
+	message, response, err := NewRollInteractionResponseFromStringWithContext(
+		WithResultInterpreter(ctx, interpret), cocExpression(bonus, penalty, label))
+	if opt := getOptionByName(options, "secret"); opt != nil && opt.BoolValue() {
+		response.Data.Flags |= discordgo.MessageFlagsEphemeral
+	}
+	if err == nil {
+		defer CacheRoll(UserFromInteraction(i), &NamedRollInput{
+			Expression: message.Expression,
+			Label:      message.Label,
+		})
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, response); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/travis-g/dice"
+)
+
+func Test_cocSuccessLevel(t *testing.T) {
+	tests := []struct {
+		roll  int
+		skill int
+		want  string
+	}{
+		{roll: 1, skill: 10, want: CocCritical},
+		{roll: 13, skill: 65, want: CocExtreme},
+		{roll: 14, skill: 65, want: CocHard},
+		{roll: 32, skill: 65, want: CocHard},
+		{roll: 33, skill: 65, want: CocRegular},
+		{roll: 65, skill: 65, want: CocRegular},
+		{roll: 66, skill: 65, want: CocFailure},
+		{roll: 96, skill: 65, want: CocFailure},
+		{roll: 100, skill: 65, want: CocFumble},
+		{roll: 96, skill: 40, want: CocFumble},
+		{roll: 95, skill: 40, want: CocFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := cocSuccessLevel(tt.roll, tt.skill); got != tt.want {
+				t.Errorf("cocSuccessLevel(%d, %d) = %v, want %v", tt.roll, tt.skill, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_percentileValue(t *testing.T) {
+	tests := []struct {
+		tens, units int
+		want        int
+	}{
+		{tens: 0, units: 0, want: 100},
+		{tens: 0, units: 1, want: 1},
+		{tens: 4, units: 0, want: 40},
+		{tens: 9, units: 9, want: 99},
+	}
+	for _, tt := range tests {
+		if got := percentileValue(tt.tens, tt.units); got != tt.want {
+			t.Errorf("percentileValue(%d, %d) = %v, want %v", tt.tens, tt.units, got, tt.want)
+		}
+	}
+}
+
+func Test_cocExpression(t *testing.T) {
+	tests := []struct {
+		bonus, penalty int
+		label          string
+		want           string
+	}{
+		{want: "d100"},
+		{bonus: 1, want: "d100b1"},
+		{penalty: 2, label: "Spot Hidden", want: "d100p2 # Spot Hidden"},
+		{bonus: 1, penalty: 1, want: "d100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := cocExpression(tt.bonus, tt.penalty, tt.label); got != tt.want {
+				t.Errorf("cocExpression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rollPercentile(t *testing.T) {
+	ctx := dice.NewContextFromContext(context.Background())
+	for _, notation := range []string{"d100b2", "d100p2"} {
+		t.Run(notation, func(t *testing.T) {
+			rolled, err := rollPercentile(ctx, testNotationRegexp("percentile").FindStringSubmatch(notation))
+			if err != nil {
+				t.Fatalf("rollPercentile() error = %v", err)
+			}
+			if n := len(rolled.Group.Group); n != 4 {
+				t.Fatalf("rollPercentile() rolled %d dice, want 4", n)
+			}
+			units := percentileDigit(rolled.Group.Group[3].(*dice.Die))
+			for _, roller := range rolled.Group.Group[:3] {
+				v := float64(percentileValue(percentileDigit(roller.(*dice.Die)), units))
+				if (notation == "d100b2" && v < rolled.Value) || (notation == "d100p2" && v > rolled.Value) {
+					t.Errorf("rollPercentile() = %v, but tens die gives %v", rolled.Value, v)
+				}
+			}
+		})
+	}
+}
@@ -106,6 +106,895 @@ var CommandsGlobalChat = []*discordgo.ApplicationCommand{
 			discordgo.SpanishES: "Comandos de eliminación de detos",
 		},
 	},
+	{
+		Name:             "history",
+		Description:      "Show your recent roll history",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "filter",
+				Description: "Only show rolls with expressions or labels containing this text",
+				MaxLength:   50,
+			},
+		},
+		NameLocalizations: &map[discordgo.Locale]string{
+			discordgo.SpanishES: "historial",
+		},
+	},
+	{
+		Name:             "mystats",
+		Description:      "Show your personal dice luck statistics",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "share",
+				Description: "Share your stats with the channel",
+			},
+		},
+		NameLocalizations: &map[discordgo.Locale]string{
+			discordgo.SpanishES: "misestadísticas",
+		},
+	},
+	{
+		Name:             "session",
+		Description:      "Track rolls made during a game session",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "start",
+				Description: "Start a session in this channel.",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "end",
+				Description: "End the session in this channel and post a summary of its rolls.",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+		},
+		NameLocalizations: &map[discordgo.Locale]string{
+			discordgo.SpanishES: "sesión",
+		},
+	},
+	{
+		Name:                     "log",
+		Description:              "Channel roll logs",
+		IntegrationTypes:         &defaultIntegrationTypes,
+		Contexts:                 &[]discordgo.InteractionContextType{discordgo.InteractionContextGuild},
+		DefaultMemberPermissions: Ptr(int64(discordgo.PermissionManageGuild)),
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "export",
+				Description: "Export the public rolls made in a channel to CSV and JSON.",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:         "channel",
+						Description:  "Channel to export rolls from (default: this channel)",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildPublicThread, discordgo.ChannelTypeGuildPrivateThread},
+					},
+					{
+						Name:        "since",
+						Description: "Only export rolls made since a UTC date, like '2024-01-31'",
+						Type:        discordgo.ApplicationCommandOptionString,
+					},
+				},
+			},
+		},
+		NameLocalizations: &map[discordgo.Locale]string{
+			discordgo.SpanishES: "registro",
+		},
+	},
+	{
+		Name:             "verify",
+		Description:      "Verify that a roll result was genuinely made by Dice Golem",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "code",
+				Description: "Receipt code shown beneath the roll result",
+				Required:    true,
+				MinLength:   Ptr(receiptTokenLength),
+				MaxLength:   receiptTokenLength + 2,
+			},
+		},
+		NameLocalizations: &map[discordgo.Locale]string{
+			discordgo.SpanishES: "verificar",
+		},
+	},
+	{
+		Name:             "check",
+		Description:      "Make a D&D 5e ability check",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options:          MergeApplicationCommandOptions(d20TestOptions, rollOptionsSecret),
+	},
+	{
+		Name:             "save",
+		Description:      "Make a D&D 5e saving throw",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options:          MergeApplicationCommandOptions(d20TestOptions, rollOptionsSecret),
+	},
+	{
+		Name:             "attack",
+		Description:      "Make a D&D 5e attack roll and roll damage on a hit",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: MergeApplicationCommandOptions(d20TestOptions, []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "damage",
+				Description:  "Damage to roll on a hit, like '1d8+3'. Dice are doubled on a critical hit",
+				Autocomplete: true,
+			},
+		}, rollOptionsSecret),
+	},
+	{
+		Name:             "pbta",
+		Description:      "Roll a Powered by the Apocalypse move",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: MergeApplicationCommandOptions([]*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "stat",
+				Description: "Stat to add to the roll, like 2 or -1",
+				MinValue:    Ptr[float64](-10),
+				MaxValue:    float64(10),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "move",
+				Description: "Move being made, like 'Defy Danger'",
+				MaxLength:   100,
+			},
+		}, rollOptionsSecret),
+	},
+	{
+		Name:             "coc",
+		Description:      "Roll a Call of Cthulhu skill roll",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: MergeApplicationCommandOptions([]*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "skill",
+				Description: "Skill or characteristic value, like 65",
+				Required:    true,
+				MinValue:    Ptr[float64](1),
+				MaxValue:    float64(200),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "bonus",
+				Description: "Number of bonus dice",
+				MinValue:    Ptr[float64](0),
+				MaxValue:    float64(2),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "penalty",
+				Description: "Number of penalty dice",
+				MinValue:    Ptr[float64](0),
+				MaxValue:    float64(2),
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "label",
+				Description:  "Roll label, like 'Spot Hidden'",
+				Autocomplete: true,
+			},
+		}, rollOptionsSecret),
+	},
+	{
+		Name:             "fitd",
+		Description:      "Make a Forged in the Dark action roll",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: MergeApplicationCommandOptions([]*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "dice",
+				Description: "Number of dice in the pool",
+				Required:    true,
+				MinValue:    Ptr[float64](0),
+				MaxValue:    float64(fitdMaxDice),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "position",
+				Description: "Position of the action",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Controlled", Value: "Controlled"},
+					{Name: "Risky", Value: "Risky"},
+					{Name: "Desperate", Value: "Desperate"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "effect",
+				Description: "Effect of the action",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Limited", Value: "Limited"},
+					{Name: "Standard", Value: "Standard"},
+					{Name: "Great", Value: "Great"},
+				},
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "label",
+				Description:  "Roll label, like 'Prowl'",
+				Autocomplete: true,
+			},
+		}, rollOptionsSecret),
+	},
+	{
+		Name:             "fate",
+		Description:      "Roll Fate dice with a skill, optionally against opposition",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: MergeApplicationCommandOptions([]*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "skill",
+				Description: "Skill rating to add to the roll, like 3",
+				MinValue:    Ptr[float64](-10),
+				MaxValue:    float64(20),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "vs",
+				Description: "Opposition to roll against, like 2",
+				MinValue:    Ptr[float64](-10),
+				MaxValue:    float64(20),
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "label",
+				Description:  "Roll label, like 'Overcome'",
+				Autocomplete: true,
+			},
+		}, rollOptionsSecret),
+	},
+	{
+		Name:             "pool",
+		Description:      "Roll a pool of narrative dice, like Genesys dice",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: MergeApplicationCommandOptions([]*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "dice",
+				Description: "Dice pool to roll, like '2g 1y 2p'",
+				Required:    true,
+				MaxLength:   100,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "system",
+				Description: "Dice system of the pool (default: Genesys)",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Genesys/Star Wars", Value: "genesys"},
+				},
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "label",
+				Description:  "Roll label, like 'Athletics'",
+				Autocomplete: true,
+			},
+		}, rollOptionsSecret),
+	},
+	{
+		Name:             "dice",
+		Description:      "Manage custom dice to roll in expressions, like '3d{dwarf}'",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "define",
+				Description: "Define a custom die with its faces",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Name of the die, like 'dwarf'",
+						Required:    true,
+						MaxLength:   32,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "faces",
+						Description: "Comma-separated faces, like '1,1,2,3,4,6' or 'Hit,Hit,Miss'",
+						Required:    true,
+						MaxLength:   1000,
+					},
+					customDiceServerOption,
+				},
+			},
+			{
+				Name:        "delete",
+				Description: "Delete a custom die",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "name",
+						Description:  "Name of the die",
+						Required:     true,
+						Autocomplete: true,
+					},
+					customDiceServerOption,
+				},
+			},
+			{
+				Name:        "list",
+				Description: "List your custom dice and this server's",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+		},
+	},
+	{
+		Name:             "table",
+		Description:      "Create and roll on random tables",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "roll",
+				Description: "Roll on a random table",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: MergeApplicationCommandOptions([]*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "name",
+						Description:  "Name of the table",
+						Required:     true,
+						Autocomplete: true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "times",
+						Description: "Number of times to roll on the table",
+						MinValue:    Ptr[float64](1),
+						MaxValue:    float64(10),
+					},
+				}, rollOptionsSecret),
+			},
+			{
+				Name:        "create",
+				Description: "Create a random table from a CSV file or form",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Name of the table, like 'encounters'",
+						Required:    true,
+						MaxLength:   32,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionAttachment,
+						Name:        "file",
+						Description: "CSV file with weight or range, and result columns",
+					},
+					tableServerOption,
+				},
+			},
+			{
+				Name:        "delete",
+				Description: "Delete a random table",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "name",
+						Description:  "Name of the table",
+						Required:     true,
+						Autocomplete: true,
+					},
+					tableServerOption,
+				},
+			},
+			{
+				Name:        "list",
+				Description: "List your random tables and this server's",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+		},
+	},
+	{
+		Name:             "deck",
+		Description:      "Draw from a deck of cards kept for this channel",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "draw",
+				Description: "Draw cards from the deck",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: MergeApplicationCommandOptions([]*discordgo.ApplicationCommandOption{
+					deckCountOption,
+				}, rollOptionsSecret),
+			},
+			{
+				Name:        "new",
+				Description: "Create and shuffle a new deck, replacing the channel's deck",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "type",
+						Description: "Type of deck",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Standard (with jokers)", Value: DeckStandard},
+							{Name: "Tarot", Value: DeckTarot},
+							{Name: "Custom", Value: DeckCustom},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "cards",
+						Description: "Comma-separated cards of a custom deck, like 'Sun,Moon,Star'",
+						MaxLength:   4000,
+					},
+				},
+			},
+			{
+				Name:        "shuffle",
+				Description: "Shuffle every card back into the deck",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "peek",
+				Description: "Secretly look at the top cards of the deck",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					deckCountOption,
+				},
+			},
+		},
+	},
+	{
+		Name:             "init",
+		Description:      "Track initiative for combat in this channel",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "join",
+				Description: "Roll initiative and join the order",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "expression",
+						Description:  "Initiative roll, like '1d20+3' (default: 1d20)",
+						Autocomplete: true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Name of the combatant, like 'Goblin' (default: your name)",
+						MaxLength:   32,
+					},
+				},
+			},
+			{
+				Name:        "list",
+				Description: "Post the initiative tracker",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "next",
+				Description: "Move to the next combatant's turn",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "clear",
+				Description: "Clear the initiative order and end combat",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+		},
+	},
+	{
+		Name:             "hp",
+		Description:      "Track hit points and conditions of combatants in initiative",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "damage",
+				Description: "Roll and apply damage to a combatant",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					hpTargetOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "amount",
+						Description: "Damage to roll, like '2d6+3'",
+						Required:    true,
+						MaxLength:   100,
+					},
+				},
+			},
+			{
+				Name:        "heal",
+				Description: "Roll and restore hit points to a combatant",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					hpTargetOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "amount",
+						Description: "Healing to roll, like '2d4+2'",
+						Required:    true,
+						MaxLength:   100,
+					},
+				},
+			},
+			{
+				Name:        "temp",
+				Description: "Give a combatant temporary hit points",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					hpTargetOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "amount",
+						Description: "Temporary hit points to roll, like '1d4+4'",
+						Required:    true,
+						MaxLength:   100,
+					},
+				},
+			},
+			{
+				Name:        "set",
+				Description: "Set a combatant's hit points",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					hpTargetOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "hp",
+						Description: "Current hit points",
+						Required:    true,
+						MinValue:    Ptr[float64](0),
+						MaxValue:    float64(100000),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "max",
+						Description: "Maximum hit points",
+						MinValue:    Ptr[float64](0),
+						MaxValue:    float64(100000),
+					},
+				},
+			},
+			{
+				Name:        "condition",
+				Description: "Add or remove a combatant's condition",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					hpTargetOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "condition",
+						Description: "Condition, like 'Prone'",
+						Required:    true,
+						MaxLength:   32,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "rounds",
+						Description: "Rounds the condition lasts, counted down on the combatant's turn",
+						MinValue:    Ptr[float64](1),
+						MaxValue:    float64(100),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "remove",
+						Description: "Remove the condition instead",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:             "clock",
+		Description:      "Track progress clocks in this channel",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "create",
+				Description: "Create a progress clock, or reset one",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "name",
+						Description: "Name of the clock, like 'Alarm'",
+						Required:    true,
+						MaxLength:   32,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "segments",
+						Description: "Number of segments",
+						Required:    true,
+						MinValue:    Ptr[float64](minClockSegments),
+						MaxValue:    float64(maxClockSegments),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "tick-on",
+						Description: "Roll that ticks the clock when met, like '1d6<=2'",
+						MaxLength:   50,
+					},
+				},
+			},
+			{
+				Name:        "tick",
+				Description: "Fill in segments of a clock",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					clockNameOption,
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "amount",
+						Description: "Segments to fill in, or clear if negative (default: 1)",
+						MinValue:    Ptr[float64](-maxClockSegments),
+						MaxValue:    float64(maxClockSegments),
+					},
+				},
+			},
+			{
+				Name:        "roll",
+				Description: "Roll a clock's tick condition",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					clockNameOption,
+				},
+			},
+			{
+				Name:        "show",
+				Description: "Post a clock",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					clockNameOption,
+				},
+			},
+			{
+				Name:        "list",
+				Description: "List this channel's clocks",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "delete",
+				Description: "Delete a clock",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					clockNameOption,
+				},
+			},
+		},
+	},
+	{
+		Name:             "contest",
+		Description:      "Challenge someone to an opposed roll",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "opponent",
+				Description: "Who to challenge",
+				Required:    true,
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "expression",
+				Description:  "Roll for the contest, like '1d20+5'",
+				Required:     true,
+				Autocomplete: true,
+				MaxLength:    100,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "label",
+				Description: "What the contest is, like 'Arm wrestling'",
+				MaxLength:   50,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "ties",
+				Description: "How ties are settled (default: draw)",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Draw", Value: ContestTiesDraw},
+					{Name: "Challenger wins", Value: ContestTiesChallenger},
+					{Name: "Opponent wins", Value: ContestTiesOpponent},
+				},
+			},
+		},
+	},
+	{
+		Name:             "grouproll",
+		Description:      "Have everyone in the channel roll the same check",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "expression",
+				Description: "Roll for everyone, with @NAME for each roller's own modifiers, like '1d20+@WIS'",
+				Required:    true,
+				MaxLength:   100,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "dc",
+				Description: "Total each roller needs to pass",
+				Required:    true,
+				MinValue:    Ptr[float64](-100),
+				MaxValue:    float64(1000),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "timeout",
+				Description: "How long the group roll is open, like '5m' (default: 2m)",
+				MaxLength:   10,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "label",
+				Description: "What the check is for, like 'Stealth'",
+				MaxLength:   50,
+			},
+		},
+	},
+	{
+		Name:                     "schedule",
+		Description:              "Schedule rolls to post to this server's channels",
+		IntegrationTypes:         &[]discordgo.ApplicationIntegrationType{discordgo.ApplicationIntegrationGuildInstall},
+		Contexts:                 &[]discordgo.InteractionContextType{discordgo.InteractionContextGuild},
+		DefaultMemberPermissions: Ptr(int64(discordgo.PermissionManageGuild)),
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "roll",
+				Description: "Schedule a roll, optionally repeating",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "expression",
+						Description:  "Roll to post, like '1d20' or 'Today: [[table:weather]]'",
+						Required:     true,
+						Autocomplete: true,
+						MaxLength:    100,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "at",
+						Description: "When to post, like '18:30' (UTC), '2024-01-31 18:30' (UTC) or '2h'",
+						Required:    true,
+						MaxLength:   32,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "every",
+						Description: "How often to repeat, like '1d' or '1w'",
+						MaxLength:   10,
+					},
+					{
+						Name:         "channel",
+						Description:  "Channel to post to (default: this channel)",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildPublicThread, discordgo.ChannelTypeGuildPrivateThread},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "label",
+						Description: "What the roll is for, like 'Weather'",
+						MaxLength:   50,
+					},
+				},
+			},
+			{
+				Name:        "list",
+				Description: "List this server's scheduled rolls",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "cancel",
+				Description: "Cancel a scheduled roll",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "roll",
+						Description:  "Scheduled roll to cancel",
+						Required:     true,
+						Autocomplete: true,
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:                     "configure",
+		Description:              "Configure game systems and roll output for this server",
+		IntegrationTypes:         &[]discordgo.ApplicationIntegrationType{discordgo.ApplicationIntegrationGuildInstall},
+		Contexts:                 &[]discordgo.InteractionContextType{discordgo.InteractionContextGuild},
+		DefaultMemberPermissions: Ptr(int64(discordgo.PermissionManageGuild)),
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "pbta",
+				Description: "Set the outcome bands of Powered by the Apocalypse moves",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "strong",
+						Description: "Minimum total for a strong hit (default: 10)",
+						MinValue:    Ptr[float64](-20),
+						MaxValue:    float64(40),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "weak",
+						Description: "Minimum total for a weak hit (default: 7)",
+						MinValue:    Ptr[float64](-20),
+						MaxValue:    float64(40),
+					},
+				},
+			},
+			{
+				Name:        "output",
+				Description: "Set how roll results are shown in this server by default",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "cards",
+						Description: "Show roll results as cards",
+						Required:    true,
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:             "data",
+		Description:      "Manage the data Dice Golem stores about you",
+		IntegrationTypes: &defaultIntegrationTypes,
+		Contexts:         &defaultContextTypes,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "export",
+				Description: "Export all of your stored data to a JSON file.",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "delete",
+				Description: "Permanently delete all of your stored data.",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+		},
+		NameLocalizations: &map[discordgo.Locale]string{
+			discordgo.SpanishES: "datos",
+		},
+	},
 	{
 		Name:             "preferences",
 		Description:      "Configure your preferences",
@@ -138,7 +1027,16 @@ var CommandsGlobalChat = []*discordgo.ApplicationCommand{
 						Type:        discordgo.ApplicationCommandOptionBoolean,
 						Name:        "detailed",
 						Description: "Prefer detailed roll output by default",
-						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "image",
+						Description: "Attach an image of the dice rolled",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "cards",
+						Description: "Show roll results in servers as cards (overrides the server default)",
 					},
 				},
 			},
@@ -160,6 +1058,11 @@ var CommandsGlobalChat = []*discordgo.ApplicationCommand{
 				Description: "Common Fate (and Fudge) system rolls.",
 				Type:        discordgo.ApplicationCommandOptionSubCommand,
 			},
+			{
+				Name:        "fitd",
+				Description: "Forged in the Dark action rolls.",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
 			{
 				Name:        "d20",
 				Description: "Modifiers for a D20 roll.",
@@ -411,6 +1314,69 @@ var (
 			Autocomplete: true,
 		},
 	}
+	d20TestOptions = []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "bonus",
+			Description: "Modifier to add to the d20, like 5 or -1",
+			MinValue:    Ptr[float64](-100),
+			MaxValue:    float64(100),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "dc",
+			Description: "Difficulty class (or armor class) to beat",
+			MinValue:    Ptr[float64](1),
+			MaxValue:    float64(100),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "advantage",
+			Description: "Roll with advantage",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "disadvantage",
+			Description: "Roll with disadvantage",
+		},
+		{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "label",
+			Description:  "Roll label, like 'Stealth'",
+			Autocomplete: true,
+		},
+	}
+	customDiceServerOption = &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionBoolean,
+		Name:        "server",
+		Description: "Manage the die for everyone in this server (requires Manage Server)",
+	}
+	deckCountOption = &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Name:        "count",
+		Description: "Number of cards (default: 1)",
+		MinValue:    Ptr[float64](1),
+		MaxValue:    float64(10),
+	}
+	hpTargetOption = &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionString,
+		Name:         "target",
+		Description:  "Combatant in the initiative order, like 'Goblin'",
+		Required:     true,
+		Autocomplete: true,
+	}
+	clockNameOption = &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionString,
+		Name:         "name",
+		Description:  "Name of the clock",
+		Required:     true,
+		Autocomplete: true,
+	}
+	tableServerOption = &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionBoolean,
+		Name:        "server",
+		Description: "Manage the table for everyone in this server (requires Manage Server)",
+	}
 	// helper to fetch a command option value given a name rather than an index
 	getOptionByName = func(opts []*discordgo.ApplicationCommandInteractionDataOption, name string) *discordgo.ApplicationCommandInteractionDataOption {
 		for _, opt := range opts {
@@ -35,6 +35,10 @@ type Config struct {
 	SelfID string `env:"ID,required"`
 	Debug  bool   `env:"DEBUG,default=false"`
 
+	// Secret key for signing roll receipts. If unset a key is generated and
+	// shared through Redis.
+	ReceiptKey string `env:"RECEIPT_KEY"`
+
 	// Top.gg token
 	TopToken *string `env:"TOP_TOKEN,noinit"`
 
@@ -50,6 +54,9 @@ type Config struct {
 	// Number of recent rolls to keep in history
 	MaxHistory int `env:"MAX_HISTORY,default=25"`
 
+	// Number of roll events to keep per channel
+	MaxEvents int `env:"MAX_EVENTS,default=1000"`
+
 	// Number of saved expressions per key
 	MaxExpressions int `env:"MAX_ROLLS,default=50"`
 
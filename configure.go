@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// InteractionConfigure configures game system settings for a guild.
+func InteractionConfigure(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "configure"}, 1)
+
+	if i.Member == nil || i.Member.Permissions&discordgo.PermissionManageGuild == 0 {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("This command requires the _Manage Server_ permission."))
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	switch options[0].Name {
+	case "pbta":
+		bands := GetPbtaBands(ctx, i.GuildID)
+		if opt := getOptionByName(options, "strong"); opt != nil {
+			bands.Strong = int(opt.IntValue())
+		}
+		if opt := getOptionByName(options, "weak"); opt != nil {
+			bands.Weak = int(opt.IntValue())
+		}
+		if !bands.Valid() {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Weak hits must need a lower total than strong hits."))
+			return
+		}
+		if err := SetPbtaBands(ctx, i.GuildID, bands); err != nil {
+			logger.Error("error setting pbta bands", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+			fmt.Sprintf("Moves rolled with %s will now be %s.", CommandMention("pbta"), bands))); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+	case "output":
+		guild := &discordgo.Guild{ID: i.GuildID}
+		content := "Rolls in this server will now be shown as text."
+		if mustGetOptionByName(options, "cards").BoolValue() {
+			GuildSetSetting(guild, SettingCards)
+			content = "Rolls in this server will now be shown as cards."
+		} else {
+			GuildUnsetSetting(guild, SettingCards)
+		}
+		content += fmt.Sprintf(" Members can override this with %s.", CommandMention("preferences", "output"))
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(content)); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry, an invalid subcommand was received!"))
+	}
+}
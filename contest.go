@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// contestTimeout is how long a challenge can be accepted for.
+const contestTimeout = 10 * time.Minute
+
+// Tie rules of contests.
+const (
+	ContestTiesDraw       = "draw"
+	ContestTiesChallenger = "challenger"
+	ContestTiesOpponent   = "opponent"
+)
+
+// A Contest is a pending challenge of one user by another to an opposed roll.
+type Contest struct {
+	ID         string `json:"id"`
+	Challenger string `json:"challenger"`
+	Opponent   string `json:"opponent"`
+	Expression string `json:"expression"`
+	Label      string `json:"label,omitempty"`
+	Ties       string `json:"ties,omitempty"`
+	// Unix time the challenge expires
+	Expires int64 `json:"expires"`
+}
+
+// contestWinner compares the results of a contest, returning 1 if the
+// challenger won, -1 if the opponent won, or 0 for a draw.
+func contestWinner(challenger, opponent float64, ties string) int {
+	switch {
+	case challenger > opponent:
+		return 1
+	case challenger < opponent:
+		return -1
+	case ties == ContestTiesChallenger:
+		return 1
+	case ties == ContestTiesOpponent:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// contestTiesDescription describes a contest's tie rule.
+func contestTiesDescription(ties string) string {
+	switch ties {
+	case ContestTiesChallenger:
+		return "the challenger wins ties"
+	case ContestTiesOpponent:
+		return "the opponent wins ties"
+	default:
+		return "ties are a draw"
+	}
+}
+
+// SetContest saves a pending contest until it expires. Contests put back after
+// a failed roll keep their original expiry.
+func SetContest(ctx context.Context, contest *Contest) error {
+	ttl := time.Until(time.Unix(contest.Expires, 0))
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(contest)
+	if err != nil {
+		return err
+	}
+	return DiceGolem.Cache.Redis.Set(ctx, fmt.Sprintf(KeyContestFmt, contest.ID), data, ttl).Err()
+}
+
+// GetContest returns a pending contest. If take is set the contest is removed,
+// so only one roll can settle it.
+func GetContest(ctx context.Context, id string, take bool) (*Contest, error) {
+	key := fmt.Sprintf(KeyContestFmt, id)
+	cmd := DiceGolem.Cache.Redis.Get
+	if take {
+		cmd = DiceGolem.Cache.Redis.GetDel
+	}
+	data, err := cmd(ctx, key).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	contest := new(Contest)
+	return contest, json.Unmarshal(data, contest)
+}
+
+// contestExpired responds that a contest can no longer be accepted.
+func contestExpired(ctx context.Context, err error) {
+	s, i, _ := FromContext(ctx)
+	if !errors.Is(err, redis.Nil) {
+		logger.Error("error getting contest", zap.Error(err))
+	}
+	MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry! That challenge has expired or was already settled."))
+}
+
+// InteractionContest challenges another user to an opposed roll.
+func InteractionContest(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "contest"}, 1)
+
+	if DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	options := data.Options
+	challenger := UserFromInteraction(i)
+	// use the resolved user rather than fetching them from the API
+	opponent := mustGetOptionByName(options, "opponent").UserValue(nil)
+	if data.Resolved != nil && data.Resolved.Users[opponent.ID] != nil {
+		opponent = data.Resolved.Users[opponent.ID]
+	}
+	if opponent.ID == challenger.ID || opponent.Bot {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("You need to challenge someone else!"))
+		return
+	}
+	contest := &Contest{
+		ID:         i.ID,
+		Challenger: challenger.ID,
+		Opponent:   opponent.ID,
+		Expression: strings.TrimSpace(mustGetOptionByName(options, "expression").StringValue()),
+		Ties:       ContestTiesDraw,
+		Expires:    time.Now().Add(contestTimeout).Unix(),
+	}
+	if opt := getOptionByName(options, "label"); opt != nil {
+		contest.Label = opt.StringValue()
+	}
+	if opt := getOptionByName(options, "ties"); opt != nil {
+		contest.Ties = opt.StringValue()
+	}
+	// make sure the challenge can be settled before the opponent accepts it
+	if _, _, err := evaluateRoll(ctx, contest.Expression); err != nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(createFriendlyError(err).Error()))
+		return
+	}
+	if err := SetContest(ctx, contest); err != nil {
+		logger.Error("error saving contest", zap.Error(err))
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s challenges %s to a contest", challenger.Mention(), opponent.Mention())
+	if contest.Label != "" {
+		fmt.Fprintf(&b, " of _%s_", contest.Label)
+	}
+	fmt.Fprintf(&b, "! They'll roll `%s`, and %s.\n-# The challenge expires <t:%d:R>.",
+		contest.Expression, contestTiesDescription(contest.Ties), contest.Expires)
+
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: b.String(),
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Users: []string{opponent.ID},
+			},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label:    "Accept and roll",
+							Style:    discordgo.PrimaryButton,
+							CustomID: newComponentID("contest", "accept", contest.ID),
+						},
+						discordgo.Button{
+							Label:    "Decline",
+							Style:    discordgo.SecondaryButton,
+							CustomID: newComponentID("contest", "decline", contest.ID),
+						},
+					},
+				},
+			},
+		},
+	}); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
+
+// makeContestRollComponents creates the choices of roll for an opponent that
+// accepted a contest: the challenger's expression, one of their saved rolls,
+// or an expression of their own.
+func makeContestRollComponents(contest *Contest, saved RollSlice) []discordgo.MessageComponent {
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    truncString("Roll "+contest.Expression, 80),
+					Style:    discordgo.PrimaryButton,
+					CustomID: newComponentID("contest", "roll", contest.ID),
+				},
+				discordgo.Button{
+					Label:    "Roll something else",
+					Style:    discordgo.SecondaryButton,
+					CustomID: newComponentID("contest", "custom", contest.ID),
+				},
+			},
+		},
+	}
+	if len(saved) == 0 {
+		return components
+	}
+	options := make([]discordgo.SelectMenuOption, 0, min(len(saved), 25))
+	for _, roll := range trunc(saved, 25) {
+		options = append(options, discordgo.SelectMenuOption{
+			Label: truncString(roll.String(), 100),
+			Value: roll.RollableString(),
+		})
+	}
+	return append(components, discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				MenuType:    discordgo.StringSelectMenu,
+				CustomID:    newComponentID("contest", "saved", contest.ID),
+				Placeholder: "Roll a saved expression",
+				Options:     options,
+			},
+		},
+	})
+}
+
+// makeContestModal creates a modal for an opponent to enter their own roll.
+func makeContestModal(contest *Contest) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: newComponentID("modal_contest", contest.ID),
+			Title:    "Accept Contest",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  "expression",
+							Label:     "Your roll",
+							Style:     discordgo.TextInputShort,
+							Value:     contest.Expression,
+							Required:  true,
+							MaxLength: 100,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// InteractionContestComponent handles the buttons and menus of a contest.
+func InteractionContestComponent(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "contest"}, 1)
+	_, args := parseComponentID(i.MessageComponentData().CustomID)
+	if len(args) < 2 || DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+		return
+	}
+	uid := UserFromInteraction(i).ID
+	id := args[1]
+
+	switch args[0] {
+	case "decline":
+		contest, err := GetContest(ctx, id, false)
+		if err != nil {
+			contestExpired(ctx, err)
+			return
+		}
+		if uid != contest.Opponent && uid != contest.Challenger {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry! That challenge isn't for you."))
+			return
+		}
+		if _, err := GetContest(ctx, id, true); err != nil {
+			contestExpired(ctx, err)
+			return
+		}
+		content := fmt.Sprintf("<@%s> declined the contest.", uid)
+		if uid == contest.Challenger {
+			content = fmt.Sprintf("<@%s> withdrew the challenge.", uid)
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    i.Message.Content + "\n" + content,
+				Components: []discordgo.MessageComponent{},
+			},
+		}); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+	case "accept":
+		contest, err := GetContest(ctx, id, false)
+		if err != nil {
+			contestExpired(ctx, err)
+			return
+		}
+		if uid != contest.Opponent {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry! That challenge isn't for you."))
+			return
+		}
+		saved, _ := GetNamedRolls(UserFromInteraction(i), i.GuildID)
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags:      discordgo.MessageFlagsEphemeral,
+				Content:    "Challenge accepted! What will you roll?",
+				Components: makeContestRollComponents(contest, saved),
+			},
+		}); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+	case "custom":
+		contest, err := GetContest(ctx, id, false)
+		if err != nil {
+			contestExpired(ctx, err)
+			return
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, makeContestModal(contest)); err != nil {
+			logger.Error("error sending modal", zap.Error(err))
+		}
+	case "roll":
+		settleContest(ctx, id, "")
+	case "saved":
+		var expression string
+		if values := i.MessageComponentData().Values; len(values) > 0 {
+			expression = values[0]
+		}
+		settleContest(ctx, id, expression)
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+	}
+}
+
+// ContestModalInteraction settles a contest with a roll entered by the
+// opponent.
+func ContestModalInteraction(ctx context.Context, data map[string]any) {
+	_, i, _ := FromContext(ctx)
+	_, args := parseComponentID(i.ModalSubmitData().CustomID)
+	expression, _ := data["expression"].(string)
+	if len(args) == 0 {
+		contestExpired(ctx, redis.Nil)
+		return
+	}
+	settleContest(ctx, args[0], expression)
+}
+
+// settleContest rolls both sides of a contest and posts the results. The
+// opponent rolls the challenger's expression unless they chose their own.
+func settleContest(ctx context.Context, id, expression string) {
+	s, i, _ := FromContext(ctx)
+	contest, err := GetContest(ctx, id, true)
+	if err != nil {
+		contestExpired(ctx, err)
+		return
+	}
+	if UserFromInteraction(i).ID != contest.Opponent {
+		// put the challenge back for its opponent
+		_ = SetContest(ctx, contest)
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry! That challenge isn't for you."))
+		return
+	}
+	if strings.TrimSpace(expression) == "" {
+		expression = contest.Expression
+	}
+
+	challenger, _, err1 := evaluateRoll(ctx, contest.Expression)
+	opponentInput := NewRollInputFromString(expression)
+	opponent, _, err2 := evaluateRoll(ctx, opponentInput.Expression)
+	if err := errors.Join(err1, err2); err != nil || challenger == nil || opponent == nil {
+		_ = SetContest(ctx, contest)
+		if err == nil {
+			err = ErrNilExpressionResult
+		}
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(createFriendlyError(err).Error()))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("## Contest")
+	if contest.Label != "" {
+		fmt.Fprintf(&b, ": %s", contest.Label)
+	}
+	fmt.Fprintf(&b, "\n<@%s> rolled `%s`: `%s` = **%v**", contest.Challenger, contest.Expression, challenger.Rolled, challenger.Result)
+	fmt.Fprintf(&b, "\n<@%s> rolled `%s`", contest.Opponent, opponentInput.Expression)
+	if opponentInput.Label != "" {
+		fmt.Fprintf(&b, " _%s_", opponentInput.Label)
+	}
+	fmt.Fprintf(&b, ": `%s` = **%v**\n", opponent.Rolled, opponent.Result)
+	switch contestWinner(challenger.Result, opponent.Result, contest.Ties) {
+	case 1:
+		fmt.Fprintf(&b, "🏆 <@%s> wins!", contest.Challenger)
+	case -1:
+		fmt.Fprintf(&b, "🏆 <@%s> wins!", contest.Opponent)
+	default:
+		b.WriteString("🤝 It's a draw!")
+	}
+
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: b.String(),
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Users: []string{},
+			},
+		},
+	}); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
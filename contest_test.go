@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func Test_contestWinner(t *testing.T) {
+	tests := []struct {
+		name       string
+		challenger float64
+		opponent   float64
+		ties       string
+		want       int
+	}{
+		{name: "challenger higher", challenger: 15, opponent: 12, want: 1},
+		{name: "opponent higher", challenger: 8, opponent: 12, want: -1},
+		{name: "tie draw", challenger: 10, opponent: 10, ties: ContestTiesDraw, want: 0},
+		{name: "tie default", challenger: 10, opponent: 10, want: 0},
+		{name: "tie challenger", challenger: 10, opponent: 10, ties: ContestTiesChallenger, want: 1},
+		{name: "tie opponent", challenger: 10, opponent: 10, ties: ContestTiesOpponent, want: -1},
+		{name: "ties ignored when higher", challenger: 11, opponent: 10, ties: ContestTiesOpponent, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contestWinner(tt.challenger, tt.opponent, tt.ties); got != tt.want {
+				t.Errorf("contestWinner() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -19,7 +19,10 @@ var (
 	KeyInteraction = contextKey("interaction")
 	KeyMessage     = contextKey("message")
 
-	KeyRollInput = contextKey("roll")
+	KeyRollInput         = contextKey("roll")
+	KeyResultInterpreter = contextKey("interpreter")
+	KeyLegacyResponse    = contextKey("legacy")
+	KeyCustomDice        = contextKey("dice")
 )
 
 // NewContext creates a child request context with supplied event data.
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// A Face is a face of a custom die. Faces show a numeric value, any number of
+// symbols, or both.
+type Face struct {
+	Value   float64  `json:"value,omitempty"`
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+// A CustomDie is a die with arbitrary faces, each equally likely to be rolled.
+type CustomDie struct {
+	Name  string `json:"name"`
+	Emoji string `json:"emoji,omitempty"`
+	Faces []Face `json:"faces"`
+}
+
+// Roll rolls the die, returning the face rolled.
+func (d *CustomDie) Roll() Face {
+	return d.Faces[dice.Source.Intn(len(d.Faces))]
+}
+
+// A Symbol is a symbol that can appear on the faces of custom dice.
+type Symbol struct {
+	Name  string
+	Emoji string
+}
+
+// A CustomDiceSystem is a set of custom dice rolled together as a pool, with
+// rules for how their symbols combine.
+type CustomDiceSystem struct {
+	Name string
+	// Dice of the system, by the code used to roll them in a pool
+	Dice map[string]*CustomDie
+	// Symbols in the order results should be shown
+	Symbols []*Symbol
+	// Implies are symbols that also count as another symbol, ex. a triumph is
+	// also a success
+	Implies map[string]string
+	// Cancels are pairs of symbols that cancel each other out
+	Cancels [][2]string
+	// Outcome interprets the net symbols of a pool (optional)
+	Outcome func(net map[string]int) string
+}
+
+// symbolFaces builds faces out of symbol abbreviations. Each spec is a face,
+// with each rune being the abbreviation of a symbol shown by the face.
+func symbolFaces(abbr map[rune]string, specs ...string) []Face {
+	faces := make([]Face, len(specs))
+	for i, spec := range specs {
+		for _, r := range spec {
+			faces[i].Symbols = append(faces[i].Symbols, abbr[r])
+		}
+	}
+	return faces
+}
+
+// Genesys narrative dice symbols.
+const (
+	SymbolSuccess   = "success"
+	SymbolFailure   = "failure"
+	SymbolAdvantage = "advantage"
+	SymbolThreat    = "threat"
+	SymbolTriumph   = "triumph"
+	SymbolDespair   = "despair"
+)
+
+// genesysAbbr are the abbreviations of Genesys symbols used to define faces.
+var genesysAbbr = map[rune]string{
+	's': SymbolSuccess,
+	'f': SymbolFailure,
+	'a': SymbolAdvantage,
+	't': SymbolThreat,
+	'T': SymbolTriumph,
+	'D': SymbolDespair,
+}
+
+// GenesysDice are the narrative dice of the Genesys and Star Wars RPG systems.
+var GenesysDice = &CustomDiceSystem{
+	Name: "Genesys",
+	Dice: map[string]*CustomDie{
+		"b": {Name: "Boost", Emoji: "🟦", Faces: symbolFaces(genesysAbbr, "", "", "s", "sa", "aa", "a")},
+		"k": {Name: "Setback", Emoji: "⬛", Faces: symbolFaces(genesysAbbr, "", "", "f", "f", "t", "t")},
+		"g": {Name: "Ability", Emoji: "🟩", Faces: symbolFaces(genesysAbbr, "", "s", "s", "ss", "a", "a", "sa", "aa")},
+		"p": {Name: "Difficulty", Emoji: "🟪", Faces: symbolFaces(genesysAbbr, "", "f", "ff", "t", "t", "t", "tt", "ft")},
+		"y": {Name: "Proficiency", Emoji: "🟨", Faces: symbolFaces(genesysAbbr, "", "s", "s", "ss", "ss", "a", "sa", "sa", "sa", "aa", "aa", "T")},
+		"r": {Name: "Challenge", Emoji: "🟥", Faces: symbolFaces(genesysAbbr, "", "f", "f", "ff", "ff", "t", "t", "ft", "ft", "tt", "tt", "D")},
+	},
+	Symbols: []*Symbol{
+		{Name: SymbolSuccess, Emoji: "✅"},
+		{Name: SymbolFailure, Emoji: "❌"},
+		{Name: SymbolAdvantage, Emoji: "⬆️"},
+		{Name: SymbolThreat, Emoji: "⬇️"},
+		{Name: SymbolTriumph, Emoji: "🌟"},
+		{Name: SymbolDespair, Emoji: "💀"},
+	},
+	Implies: map[string]string{
+		SymbolTriumph: SymbolSuccess,
+		SymbolDespair: SymbolFailure,
+	},
+	Cancels: [][2]string{
+		{SymbolSuccess, SymbolFailure},
+		{SymbolAdvantage, SymbolThreat},
+	},
+	Outcome: func(net map[string]int) string {
+		if net[SymbolSuccess] > 0 {
+			return "Success!"
+		}
+		return "Failure!"
+	},
+}
+
+// customDiceSystems are the registered CustomDiceSystems, by name.
+var customDiceSystems = map[string]*CustomDiceSystem{
+	"genesys": GenesysDice,
+}
+
+// maxCustomPool is the most custom dice that can be rolled in a pool.
+const maxCustomPool = 50
+
+var customPoolRegexp = regexp.MustCompile(`(\d*)\s*([[:alpha:]]+)`)
+
+// ParseCustomPool parses a pool of a system's custom dice like "2g 1y 2p",
+// returning the dice to roll in the order they were given.
+func (s *CustomDiceSystem) ParseCustomPool(pool string) ([]*CustomDie, error) {
+	var rolled []*CustomDie
+	rest := pool
+	for _, match := range customPoolRegexp.FindAllStringSubmatch(pool, -1) {
+		rest = strings.Replace(rest, match[0], "", 1)
+		die, ok := s.Dice[strings.ToLower(match[2])]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown die %q", ErrInvalidPool, match[2])
+		}
+		count := 1
+		if match[1] != "" {
+			var err error
+			if count, err = strconv.Atoi(match[1]); err != nil {
+				return nil, ErrTooManyDice
+			}
+		}
+		if count > maxCustomPool-len(rolled) {
+			return nil, ErrTooManyDice
+		}
+		for range count {
+			rolled = append(rolled, die)
+		}
+	}
+	if len(rolled) == 0 || strings.TrimSpace(rest) != "" {
+		return nil, ErrInvalidPool
+	}
+	return rolled, nil
+}
+
+// A RolledFace is the face rolled on a custom die.
+type RolledFace struct {
+	Die  *CustomDie
+	Face Face
+}
+
+// CustomPoolResult is the result of rolling a pool of custom dice.
+type CustomPoolResult struct {
+	System *CustomDiceSystem
+	Faces  []RolledFace
+	// Net symbols after cancellation
+	Net map[string]int
+}
+
+// RollCustomPool rolls a pool of a system's custom dice.
+func (s *CustomDiceSystem) RollCustomPool(pool []*CustomDie) *CustomPoolResult {
+	res := &CustomPoolResult{System: s}
+	for _, die := range pool {
+		res.Faces = append(res.Faces, RolledFace{Die: die, Face: die.Roll()})
+	}
+	res.Net = s.netSymbols(res.Faces)
+	return res
+}
+
+// netSymbols totals the symbols of rolled faces and cancels opposing symbols.
+func (s *CustomDiceSystem) netSymbols(faces []RolledFace) map[string]int {
+	net := make(map[string]int)
+	for _, face := range faces {
+		for _, symbol := range face.Face.Symbols {
+			net[symbol]++
+			if implied, ok := s.Implies[symbol]; ok {
+				net[implied]++
+			}
+		}
+	}
+	for _, pair := range s.Cancels {
+		n := min(net[pair[0]], net[pair[1]])
+		net[pair[0]] -= n
+		net[pair[1]] -= n
+	}
+	for symbol, n := range net {
+		if n == 0 {
+			delete(net, symbol)
+		}
+	}
+	return net
+}
+
+// symbolEmoji returns the emoji of a system's symbol, or the symbol itself.
+func (s *CustomDiceSystem) symbolEmoji(name string) string {
+	i := slices.IndexFunc(s.Symbols, func(symbol *Symbol) bool { return symbol.Name == name })
+	if i < 0 || s.Symbols[i].Emoji == "" {
+		return name
+	}
+	return s.Symbols[i].Emoji
+}
+
+// Markdown renders each rolled die and the symbols of its face.
+func (r *CustomPoolResult) Markdown() string {
+	parts := make([]string, len(r.Faces))
+	for i, face := range r.Faces {
+		var b strings.Builder
+		b.WriteString(face.Die.Emoji)
+		for _, symbol := range face.Face.Symbols {
+			b.WriteString(r.System.symbolEmoji(symbol))
+		}
+		if len(face.Face.Symbols) == 0 {
+			b.WriteString("➖")
+		}
+		parts[i] = b.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// String lists the net symbols of the result, ex. "1 ✅ success".
+func (r *CustomPoolResult) String() string {
+	var parts []string
+	for _, symbol := range r.System.Symbols {
+		if n, ok := r.Net[symbol.Name]; ok {
+			parts = append(parts, fmt.Sprintf("%d %s %s", n, symbol.Emoji, symbol.Name))
+		}
+	}
+	if len(parts) == 0 {
+		return "Nothing"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Outcome interprets the result with the system's rules, if any.
+func (r *CustomPoolResult) Outcome() string {
+	if r.System.Outcome == nil {
+		return ""
+	}
+	return r.System.Outcome(r.Net)
+}
+
+// InteractionPool rolls a pool of custom dice.
+func InteractionPool(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "pool"}, 1)
+
+	options := i.ApplicationCommandData().Options
+	notation := strings.TrimSpace(mustGetOptionByName(options, "dice").StringValue())
+	system := GenesysDice
+	if opt := getOptionByName(options, "system"); opt != nil {
+		if sys, ok := customDiceSystems[opt.StringValue()]; ok {
+			system = sys
+		}
+	}
+	pool, err := system.ParseCustomPool(notation)
+	if err != nil {
+		codes := make([]string, 0, len(system.Dice))
+		for code, die := range system.Dice {
+			codes = append(codes, fmt.Sprintf("`%s` %s %s", code, die.Emoji, die.Name))
+		}
+		slices.Sort(codes)
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+			fmt.Sprintf("Sorry! That pool couldn't be read. Pools are made of %s dice like `2g 1y 2p`:\n%s",
+				system.Name, strings.Join(codes, "\n"))))
+		return
+	}
+	res := system.RollCustomPool(pool)
+
+	var b strings.Builder
+	b.WriteString(ResponsePrefix)
+	if i.Member != nil && isInteractionPublic(i) {
+		b.WriteString(UserFromInteraction(i).Mention() + " rolled")
+	}
+	fmt.Fprintf(&b, " `%s`", notation)
+	if opt := getOptionByName(options, "label"); opt != nil {
+		fmt.Fprintf(&b, " _%s_", opt.StringValue())
+	}
+	fmt.Fprintf(&b, ": %s = **%s**", res.Markdown(), res.String())
+	if outcome := res.Outcome(); outcome != "" {
+		fmt.Fprintf(&b, "\n**%s**", outcome)
+	}
+
+	response := &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: b.String(),
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Users: []string{},
+			},
+		},
+	}
+	if opt := getOptionByName(options, "secret"); opt != nil && opt.BoolValue() {
+		response.Data.Flags = discordgo.MessageFlagsEphemeral
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, response); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCustomDiceSystem_ParseCustomPool(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    string
+		want    []string
+		wantErr error
+	}{
+		{name: "pool", pool: "2g 1y 2p", want: []string{"Ability", "Ability", "Proficiency", "Difficulty", "Difficulty"}},
+		{name: "compact", pool: "g2P", want: []string{"Ability", "Difficulty", "Difficulty"}},
+		{name: "unknown", pool: "2x", wantErr: ErrInvalidPool},
+		{name: "garbage", pool: "2g + 3", wantErr: ErrInvalidPool},
+		{name: "empty", pool: "", wantErr: ErrInvalidPool},
+		{name: "too many", pool: "60g", wantErr: ErrTooManyDice},
+		{name: "overflowing count", pool: "1g 9223372036854775807g", wantErr: ErrTooManyDice},
+		{name: "out of range count", pool: "99999999999999999999g", wantErr: ErrTooManyDice},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, err := GenesysDice.ParseCustomPool(tt.pool)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseCustomPool() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, die := range pool {
+				got = append(got, die.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCustomPool() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomDiceSystem_netSymbols(t *testing.T) {
+	face := func(spec string) RolledFace {
+		return RolledFace{Face: symbolFaces(genesysAbbr, spec)[0]}
+	}
+	tests := []struct {
+		name  string
+		faces []RolledFace
+		want  map[string]int
+	}{
+		{name: "cancel", faces: []RolledFace{face("ss"), face("f"), face("a"), face("tt")}, want: map[string]int{SymbolSuccess: 1, SymbolThreat: 1}},
+		{name: "triumph", faces: []RolledFace{face("T"), face("ff")}, want: map[string]int{SymbolFailure: 1, SymbolTriumph: 1}},
+		{name: "wash", faces: []RolledFace{face("s"), face("f"), face("")}, want: map[string]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenesysDice.netSymbols(tt.faces); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("netSymbols() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomPoolResult_String(t *testing.T) {
+	res := &CustomPoolResult{System: GenesysDice, Net: map[string]int{SymbolThreat: 2, SymbolSuccess: 1}}
+	if got, want := res.String(), "1 ✅ success, 2 ⬇️ threat"; got != want {
+		t.Errorf("CustomPoolResult.String() = %v, want %v", got, want)
+	}
+	if got, want := res.Outcome(), "Success!"; got != want {
+		t.Errorf("CustomPoolResult.Outcome() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// UserData is the collection of everything stored about a single user, used
+// for personal data exports.
+type UserData struct {
+	ID string `json:"id"`
+
+	Preferences        []string            `json:"preferences"`
+	GuildPreferences   map[string][]string `json:"guild_preferences,omitempty"`
+	ChannelPreferences map[string][]string `json:"channel_preferences,omitempty"`
+
+	Recent      []*UserRecentRoll `json:"recent"`
+	History     []*HistoryEntry   `json:"history"`
+	Expressions RollSlice         `json:"expressions"`
+	CustomDice  []*CustomDie      `json:"custom_dice,omitempty"`
+	Tables      []*Table          `json:"tables,omitempty"`
+	Modifiers   map[string]int    `json:"modifiers,omitempty"`
+
+	// Roll counters and tracking
+	Rolls   int64             `json:"rolls"`
+	Dice    map[string]string `json:"dice,omitempty"`
+	Streaks map[string]string `json:"streaks,omitempty"`
+	Tracked bool              `json:"tracked"`
+}
+
+// UserRecentRoll is a roll from a user's recent roll history along with the
+// time it was made.
+type UserRecentRoll struct {
+	*NamedRollInput
+	Time time.Time `json:"time"`
+}
+
+// userDataKeys returns every storage key that holds data linked to a user.
+func userDataKeys(ctx context.Context, uid string) []string {
+	keys := []string{
+		fmt.Sprintf(KeyUserPreferencesFmt, uid),
+		fmt.Sprintf(KeyCacheUserRecentFmt, uid),
+		fmt.Sprintf(KeyCacheUserHistoryFmt, uid),
+		fmt.Sprintf(KeyCacheUserGlobalExpressionsFmt, uid),
+		fmt.Sprintf(KeyCacheUserDiceFmt, uid),
+		fmt.Sprintf(KeyCacheUserTablesFmt, uid),
+		fmt.Sprintf(KeyCacheUserModifiersFmt, uid),
+		fmt.Sprintf(KeyUserRollsTotalFmt, uid),
+		fmt.Sprintf(KeyUserRollsDiceFmt, uid),
+		fmt.Sprintf(KeyUserRollsStreaksFmt, uid),
+	}
+	// guild and channel scoped preferences and expressions
+	keys = append(keys, DiceGolem.Cache.Redis.Keys(ctx, fmt.Sprintf(KeyUserPreferencesFmt, uid)+":*").Val()...)
+	keys = append(keys, DiceGolem.Cache.Redis.Keys(ctx, fmt.Sprintf(KeyCacheUserGuildExpressionsFmt, uid, "*")).Val()...)
+
+	uniques := make(map[string]bool)
+	distinct := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !uniques[key] {
+			uniques[key] = true
+			distinct = append(distinct, key)
+		}
+	}
+	return distinct
+}
+
+// CollectUserData gathers all of the data stored about a user.
+func CollectUserData(ctx context.Context, u *discordgo.User) (*UserData, error) {
+	if DiceGolem.Cache.Redis == nil {
+		return nil, ErrNoRedisClient
+	}
+	data := &UserData{
+		ID:                 u.ID,
+		GuildPreferences:   make(map[string][]string),
+		ChannelPreferences: make(map[string][]string),
+	}
+
+	prefix := fmt.Sprintf(KeyUserPreferencesFmt, u.ID)
+	data.Preferences = DiceGolem.Cache.Redis.SMembers(ctx, prefix).Val()
+	for _, key := range DiceGolem.Cache.Redis.Keys(ctx, prefix+":*").Val() {
+		values := DiceGolem.Cache.Redis.SMembers(ctx, key).Val()
+		if id, ok := strings.CutPrefix(key, prefix+":guild:"); ok {
+			data.GuildPreferences[id] = values
+		} else if id, ok := strings.CutPrefix(key, prefix+":chan:"); ok {
+			data.ChannelPreferences[id] = values
+		}
+	}
+
+	recents := DiceGolem.Cache.Redis.ZRevRangeWithScores(ctx, fmt.Sprintf(KeyCacheUserRecentFmt, u.ID), 0, -1).Val()
+	data.Recent = make([]*UserRecentRoll, len(recents))
+	for i, z := range recents {
+		roll := new(NamedRollInput)
+		roll.Deserialize(z.Member.(string))
+		data.Recent[i] = &UserRecentRoll{
+			NamedRollInput: roll,
+			Time:           time.UnixMilli(int64(z.Score)).UTC(),
+		}
+	}
+
+	data.History, _ = GetHistory(ctx, u)
+	data.Expressions, _ = GetNamedRolls(u, "")
+	for _, die := range GetCustomDice(ctx, u.ID, "") {
+		data.CustomDice = append(data.CustomDice, die)
+	}
+	slices.SortFunc(data.CustomDice, func(a, b *CustomDie) int { return strings.Compare(a.Name, b.Name) })
+	for _, table := range GetTables(ctx, u.ID, "") {
+		data.Tables = append(data.Tables, table)
+	}
+	slices.SortFunc(data.Tables, func(a, b *Table) int { return strings.Compare(a.Name, b.Name) })
+	data.Modifiers = GetModifiers(ctx, u.ID)
+
+	data.Rolls, _ = DiceGolem.Cache.Redis.Get(ctx, fmt.Sprintf(KeyUserRollsTotalFmt, u.ID)).Int64()
+	data.Dice = DiceGolem.Cache.Redis.HGetAll(ctx, fmt.Sprintf(KeyUserRollsDiceFmt, u.ID)).Val()
+	data.Streaks = DiceGolem.Cache.Redis.HGetAll(ctx, fmt.Sprintf(KeyUserRollsStreaksFmt, u.ID)).Val()
+	data.Tracked = DiceGolem.Cache.Redis.SIsMember(ctx, KeyRollsUsers, u.ID).Val()
+
+	return data, nil
+}
+
+// DeleteUserData removes all data stored about a user, including roll counters
+// and set memberships.
+func DeleteUserData(ctx context.Context, u *discordgo.User) error {
+	if DiceGolem.Cache.Redis == nil {
+		return ErrNoRedisClient
+	}
+	keys := userDataKeys(ctx, u.ID)
+	_, err := DiceGolem.Cache.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, keys...)
+		pipe.SRem(ctx, KeyRollsUsers, u.ID)
+		return nil
+	})
+	// purge any locally cached copies
+	for _, key := range keys {
+		DiceGolem.Cache.Remove(key)
+	}
+	return err
+}
+
+// ExportUserData encodes a user's data as a JSON file attachment.
+func ExportUserData(ctx context.Context, data *UserData) (*discordgo.File, error) {
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &discordgo.File{
+		Name:        "dice-golem-data.json",
+		ContentType: "application/json; charset=utf-8",
+		Reader:      bytes.NewReader(out),
+	}, nil
+}
+
+// InteractionData handles personal data export and deletion requests.
+func InteractionData(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	u := UserFromInteraction(i)
+
+	options := i.ApplicationCommandData().Options
+	switch options[0].Name {
+	case "export":
+		data, err := CollectUserData(ctx, u)
+		if err != nil {
+			logger.Error("error collecting user data", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Something unexpected errored! Please try again later."))
+			return
+		}
+		file, err := ExportUserData(ctx, data)
+		if err != nil {
+			logger.Error("error exporting user data", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Something unexpected errored! Please try again later."))
+			return
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags:   discordgo.MessageFlagsEphemeral,
+				Content: "Exported all of the data Dice Golem has stored about you. Be sure to download it!",
+				Files:   []*discordgo.File{file},
+			},
+		}); err != nil {
+			logger.Error("error sending export", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Something unexpected errored! The bot may be missing the _Attach Files_ permission."))
+		}
+	case "delete":
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags:   discordgo.MessageFlagsEphemeral,
+				Content: "This will permanently delete all of your preferences, roll history, saved expressions, and roll counts. Are you sure?",
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{
+						Components: []discordgo.MessageComponent{
+							discordgo.Button{
+								Label:    "Delete my data",
+								Style:    discordgo.DangerButton,
+								CustomID: newComponentID("data", "delete"),
+							},
+							discordgo.Button{
+								Label:    "Cancel",
+								Style:    discordgo.SecondaryButton,
+								CustomID: newComponentID("data", "cancel"),
+							},
+						},
+					},
+				},
+			},
+		}); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry! That subcommand does not have a handler yet."))
+	}
+}
+
+// InteractionDataComponent handles the confirmation buttons of a data deletion
+// request.
+func InteractionDataComponent(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	_, args := parseComponentID(i.MessageComponentData().CustomID)
+
+	content := "No data was deleted."
+	if len(args) > 0 && args[0] == "delete" {
+		if err := DeleteUserData(ctx, UserFromInteraction(i)); err != nil {
+			logger.Error("error deleting user data", zap.Error(err))
+			content = "Something unexpected errored! Please try again later."
+		} else {
+			content = "Deleted all of your data."
+		}
+	}
+
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	}); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
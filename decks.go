@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/redis/go-redis/v9"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// Limits of card decks.
+const (
+	maxDeckCards      = 200
+	maxDeckCardLength = 50
+	maxDeckDraw       = 10
+)
+
+// Types of card decks.
+const (
+	DeckStandard = "standard"
+	DeckTarot    = "tarot"
+	DeckCustom   = "custom"
+)
+
+// ErrInvalidDeck is returned for custom decks that can't be created.
+var ErrInvalidDeck = fmt.Errorf("Custom decks need a comma-separated list of up to %d cards, like `Sun,Moon,Star`.", maxDeckCards)
+
+// A Deck is a channel's deck of cards. The cards not yet drawn are kept in a
+// separate pile so draws don't repeat until the deck is shuffled.
+type Deck struct {
+	Type  string   `json:"type"`
+	Cards []string `json:"cards"`
+}
+
+// standardCards returns a standard deck of 52 playing cards and 2 jokers.
+func standardCards() []string {
+	ranks := []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
+	suits := []string{"♠", "♥", "♦", "♣"}
+	cards := make([]string, 0, len(ranks)*len(suits)+2)
+	for _, suit := range suits {
+		for _, rank := range ranks {
+			cards = append(cards, rank+suit)
+		}
+	}
+	return append(cards, "🃏 Red Joker", "🃏 Black Joker")
+}
+
+// tarotCards returns a tarot deck of 22 major and 56 minor arcana.
+func tarotCards() []string {
+	cards := []string{
+		"The Fool", "The Magician", "The High Priestess", "The Empress",
+		"The Emperor", "The Hierophant", "The Lovers", "The Chariot", "Strength",
+		"The Hermit", "Wheel of Fortune", "Justice", "The Hanged Man", "Death",
+		"Temperance", "The Devil", "The Tower", "The Star", "The Moon", "The Sun",
+		"Judgement", "The World",
+	}
+	ranks := []string{"Ace", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten", "Page", "Knight", "Queen", "King"}
+	for _, suit := range []string{"Wands", "Cups", "Swords", "Pentacles"} {
+		for _, rank := range ranks {
+			cards = append(cards, rank+" of "+suit)
+		}
+	}
+	return cards
+}
+
+// NewDeck creates a deck of a type. Custom decks are made of a
+// comma-separated list of cards.
+func NewDeck(kind, custom string) (*Deck, error) {
+	deck := &Deck{Type: kind}
+	switch kind {
+	case DeckStandard:
+		deck.Cards = standardCards()
+	case DeckTarot:
+		deck.Cards = tarotCards()
+	case DeckCustom:
+		for _, card := range strings.Split(custom, ",") {
+			card = strings.TrimSpace(card)
+			if card == "" || len(card) > maxDeckCardLength {
+				return nil, ErrInvalidDeck
+			}
+			deck.Cards = append(deck.Cards, card)
+		}
+		if len(deck.Cards) < 1 || len(deck.Cards) > maxDeckCards {
+			return nil, ErrInvalidDeck
+		}
+	default:
+		return nil, ErrInvalidDeck
+	}
+	return deck, nil
+}
+
+// shuffleCards returns a shuffled copy of cards.
+func shuffleCards(cards []string) []string {
+	shuffled := slices.Clone(cards)
+	for i := len(shuffled) - 1; i > 0; i-- {
+		j := dice.Source.Intn(i + 1)
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	return shuffled
+}
+
+// GetDeck returns the deck of a channel.
+func GetDeck(ctx context.Context, cid string) (*Deck, error) {
+	data, err := DiceGolem.Cache.Redis.Get(ctx, fmt.Sprintf(KeyChannelDeckFmt, cid)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	deck := new(Deck)
+	return deck, json.Unmarshal(data, deck)
+}
+
+// ShuffleDeck saves a channel's deck and shuffles all of its cards into its
+// draw pile.
+func ShuffleDeck(ctx context.Context, cid string, deck *Deck) error {
+	data, err := json.Marshal(deck)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf(KeyChannelDeckFmt, cid)
+	pile := fmt.Sprintf(KeyChannelDeckPileFmt, cid)
+	cards := make([]any, len(deck.Cards))
+	for i, card := range shuffleCards(deck.Cards) {
+		cards[i] = card
+	}
+	_, err = DiceGolem.Cache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, DiceGolem.DataTTL)
+		pipe.Del(ctx, pile)
+		pipe.RPush(ctx, pile, cards...)
+		pipe.Expire(ctx, pile, DiceGolem.DataTTL)
+		return nil
+	})
+	return err
+}
+
+// DrawCards draws cards off the top of a channel's draw pile, returning the
+// cards drawn and the number of cards left.
+func DrawCards(ctx context.Context, cid string, count int) ([]string, int64, error) {
+	pile := fmt.Sprintf(KeyChannelDeckPileFmt, cid)
+	var (
+		pop  *redis.StringSliceCmd
+		left *redis.IntCmd
+	)
+	_, err := DiceGolem.Cache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pop = pipe.LPopCount(ctx, pile, count)
+		left = pipe.LLen(ctx, pile)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, err
+	}
+	return pop.Val(), left.Val(), nil
+}
+
+// markdownCards renders a list of cards.
+func markdownCards(cards []string) string {
+	parts := make([]string, len(cards))
+	for i, card := range cards {
+		parts[i] = "**" + card + "**"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// InteractionDeck manages and draws from a channel's deck of cards.
+func InteractionDeck(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "deck"}, 1)
+
+	if DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	subcommand := options[0].Name
+	options = options[0].Options
+
+	if subcommand == "new" {
+		var custom string
+		if opt := getOptionByName(options, "cards"); opt != nil {
+			custom = opt.StringValue()
+		}
+		deck, err := NewDeck(mustGetOptionByName(options, "type").StringValue(), custom)
+		if err != nil {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(err.Error()))
+			return
+		}
+		if err := ShuffleDeck(ctx, i.ChannelID, deck); err != nil {
+			logger.Error("error creating deck", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("Shuffled a new %s deck of %s! Use %s to draw from it.",
+					deck.Type, pluralize(len(deck.Cards), "card", "cards"), CommandMention("deck", "draw")),
+			},
+		}); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+		return
+	}
+
+	deck, err := GetDeck(ctx, i.ChannelID)
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Error("error getting deck", zap.Error(err))
+		}
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+			fmt.Sprintf("There is no deck in this channel. Use %s to create one.", CommandMention("deck", "new"))))
+		return
+	}
+	count := 1
+	if opt := getOptionByName(options, "count"); opt != nil {
+		count = min(max(int(opt.IntValue()), 1), maxDeckDraw)
+	}
+
+	switch subcommand {
+	case "draw":
+		cards, left, err := DrawCards(ctx, i.ChannelID, count)
+		if err != nil {
+			logger.Error("error drawing cards", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if len(cards) == 0 {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+				fmt.Sprintf("The deck is empty! Use %s to shuffle the cards back in.", CommandMention("deck", "shuffle"))))
+			return
+		}
+		var b strings.Builder
+		b.WriteString(ResponsePrefix)
+		if i.Member != nil && isInteractionPublic(i) {
+			b.WriteString(UserFromInteraction(i).Mention() + " drew")
+		} else {
+			b.WriteString("Drew")
+		}
+		fmt.Fprintf(&b, " %s: %s (%d left)", pluralize(len(cards), "card", "cards"), markdownCards(cards), left)
+		response := &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: b.String(),
+				AllowedMentions: &discordgo.MessageAllowedMentions{
+					Users: []string{},
+				},
+			},
+		}
+		if opt := getOptionByName(options, "secret"); opt != nil && opt.BoolValue() {
+			response.Data.Flags = discordgo.MessageFlagsEphemeral
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, response); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+	case "peek":
+		cards, err := DiceGolem.Cache.Redis.LRange(ctx, fmt.Sprintf(KeyChannelDeckPileFmt, i.ChannelID), 0, int64(count-1)).Result()
+		if err != nil {
+			logger.Error("error peeking at cards", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		content := "The deck is empty!"
+		if len(cards) > 0 {
+			content = "The top of the deck: " + markdownCards(cards)
+		}
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(content))
+	case "shuffle":
+		if err := ShuffleDeck(ctx, i.ChannelID, deck); err != nil {
+			logger.Error("error shuffling deck", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("Shuffled all %s of the %s deck.", pluralize(len(deck.Cards), "card", "cards"), deck.Type),
+			},
+		}); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry, an invalid subcommand was received!"))
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNewDeck(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		custom  string
+		want    int
+		wantErr error
+	}{
+		{name: "standard", kind: DeckStandard, want: 54},
+		{name: "tarot", kind: DeckTarot, want: 78},
+		{name: "custom", kind: DeckCustom, custom: "Sun, Moon,Star", want: 3},
+		{name: "custom empty card", kind: DeckCustom, custom: "Sun,,Star", wantErr: ErrInvalidDeck},
+		{name: "custom empty", kind: DeckCustom, wantErr: ErrInvalidDeck},
+		{name: "unknown", kind: "uno", wantErr: ErrInvalidDeck},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deck, err := NewDeck(tt.kind, tt.custom)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewDeck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(deck.Cards) != tt.want {
+				t.Errorf("NewDeck() cards = %d, want %d", len(deck.Cards), tt.want)
+			}
+			distinct := slices.Compact(slices.Sorted(slices.Values(deck.Cards)))
+			if len(distinct) != len(deck.Cards) {
+				t.Errorf("NewDeck() has duplicate cards")
+			}
+		})
+	}
+}
+
+func Test_shuffleCards(t *testing.T) {
+	cards := standardCards()
+	shuffled := shuffleCards(cards)
+	if len(shuffled) != len(cards) {
+		t.Fatalf("shuffleCards() = %d cards, want %d", len(shuffled), len(cards))
+	}
+	if !slices.Equal(slices.Sorted(slices.Values(shuffled)), slices.Sorted(slices.Values(cards))) {
+		t.Errorf("shuffleCards() changed the cards of the deck")
+	}
+	if cards[0] != "A♠" {
+		t.Errorf("shuffleCards() modified its input")
+	}
+}
+
+func Test_markdownCards(t *testing.T) {
+	if got, want := markdownCards([]string{"A♠", "10♥"}), "**A♠**, **10♥**"; got != want {
+		t.Errorf("markdownCards() = %v, want %v", got, want)
+	}
+}
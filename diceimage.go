@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// Layout of rendered dice images, in pixels. Rolls with more dice than
+// maxImageDice aren't rendered.
+const (
+	imageDieCell    = 72
+	imageDieRadius  = 30
+	imagePadding    = 8
+	imageMaxColumns = 10
+	maxImageDice    = 50
+)
+
+// Colors of rendered dice.
+var (
+	imageDieFill      = color.RGBA{0x58, 0x65, 0xf2, 0xff}
+	imageDieCritFill  = color.RGBA{0x3b, 0xa5, 0x5c, 0xff}
+	imageDieFumFill   = color.RGBA{0xed, 0x42, 0x45, 0xff}
+	imageDieDropFill  = color.RGBA{0x4f, 0x54, 0x5c, 0xff}
+	imageDieText      = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	imageDieDropText  = color.RGBA{0xb9, 0xbb, 0xbe, 0xff}
+	imageDieEdgeShade = 0.6
+)
+
+// imageGlyphs is a 3x5 bitmap font of the characters drawn on dice.
+var imageGlyphs = map[rune][5]string{
+	'0': {"111", "101", "101", "101", "111"},
+	'1': {"010", "110", "010", "010", "111"},
+	'2': {"111", "001", "111", "100", "111"},
+	'3': {"111", "001", "111", "001", "111"},
+	'4': {"101", "101", "111", "001", "001"},
+	'5': {"111", "100", "111", "001", "111"},
+	'6': {"111", "100", "111", "101", "111"},
+	'7': {"111", "001", "001", "001", "001"},
+	'8': {"111", "101", "111", "101", "111"},
+	'9': {"111", "101", "111", "001", "111"},
+	'+': {"000", "010", "111", "010", "000"},
+	'-': {"000", "000", "111", "000", "000"},
+}
+
+// A point is a point of a polygon.
+type point struct{ X, Y float64 }
+
+// regularPolygon returns the points of a regular polygon with a number of
+// sides around the origin with a radius of 1, turned by an angle in radians.
+func regularPolygon(sides int, turn float64) []point {
+	points := make([]point, sides)
+	for k := range points {
+		a := turn + 2*math.Pi*float64(k)/float64(sides)
+		points[k] = point{math.Cos(a), math.Sin(a)}
+	}
+	return points
+}
+
+// dieShape returns the outline of a die around the origin with a radius of
+// about 1, shaped like the polyhedron it stands for.
+func dieShape(d *dice.Die) []point {
+	up := -math.Pi / 2
+	square := []point{{-0.85, -0.85}, {0.85, -0.85}, {0.85, 0.85}, {-0.85, 0.85}}
+	if d.Type == dice.TypeFudge {
+		return square
+	}
+	switch d.Size {
+	case 4:
+		return regularPolygon(3, up)
+	case 6:
+		return square
+	case 8:
+		return regularPolygon(4, up)
+	case 10, 100:
+		return []point{{0, -1}, {0.95, 0.1}, {0, 0.85}, {-0.95, 0.1}}
+	case 12:
+		return regularPolygon(5, up)
+	case 20:
+		return regularPolygon(6, up)
+	default:
+		return regularPolygon(24, 0)
+	}
+}
+
+// dieLabel returns the text drawn on a die: its value, or the symbol of a
+// fudge die's face.
+func dieLabel(d *dice.Die) string {
+	if d.Type == dice.TypeFudge {
+		switch {
+		case d.Result.Value > 0:
+			return "+"
+		case d.Result.Value < 0:
+			return "-"
+		default:
+			return ""
+		}
+	}
+	return strconv.FormatFloat(d.Result.Value, 'f', 0, 64)
+}
+
+// dieColors returns the fill and text colors of a die. Dropped dice are greyed
+// out, and polyhedral dice that rolled their highest or lowest face are
+// colored as crits or fumbles.
+func dieColors(ctx context.Context, d *dice.Die) (fill, text color.RGBA) {
+	switch {
+	case d.IsDropped(ctx):
+		return imageDieDropFill, imageDieDropText
+	case d.Type == dice.TypeFudge || d.Size < 2:
+		return imageDieFill, imageDieText
+	case d.Result.CritSuccess || int(d.Result.Value) == d.Size:
+		return imageDieCritFill, imageDieText
+	case d.Result.CritFailure || d.Result.Value == 1:
+		return imageDieFumFill, imageDieText
+	default:
+		return imageDieFill, imageDieText
+	}
+}
+
+// shade darkens a color by a factor.
+func shade(c color.RGBA, f float64) color.RGBA {
+	return color.RGBA{uint8(float64(c.R) * f), uint8(float64(c.G) * f), uint8(float64(c.B) * f), c.A}
+}
+
+// insidePolygon returns whether a point is inside a polygon, by casting a ray
+// from it and counting the edges crossed.
+func insidePolygon(p point, poly []point) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// fillPolygon fills a polygon scaled by r around a center point.
+func fillPolygon(img *image.RGBA, shape []point, cx, cy, r float64, c color.RGBA) {
+	poly := make([]point, len(shape))
+	for i, p := range shape {
+		poly[i] = point{cx + p.X*r, cy + p.Y*r}
+	}
+	for y := int(cy - r - 1); y <= int(cy+r+1); y++ {
+		for x := int(cx - r - 1); x <= int(cx+r+1); x++ {
+			if insidePolygon(point{float64(x) + 0.5, float64(y) + 0.5}, poly) {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// drawText draws text in the bitmap font centered on a point.
+func drawText(img *image.RGBA, text string, cx, cy int, c color.RGBA) {
+	scale := 4
+	if len(text) > 2 {
+		scale = 3
+	}
+	width := len(text)*4*scale - scale
+	x0, y0 := cx-width/2, cy-5*scale/2
+	for n, ch := range text {
+		glyph, ok := imageGlyphs[ch]
+		if !ok {
+			continue
+		}
+		for row, bits := range glyph {
+			for col, bit := range bits {
+				if bit != '1' {
+					continue
+				}
+				for dy := range scale {
+					for dx := range scale {
+						img.SetRGBA(x0+(n*4+col)*scale+dx, y0+row*scale+dy, c)
+					}
+				}
+			}
+		}
+	}
+}
+
+// RenderDiceImage draws each die rolled within a set of groups into a PNG
+// image. Nil is returned if there are no dice or too many to draw.
+func RenderDiceImage(ctx context.Context, groups []*dice.RollerGroup) ([]byte, error) {
+	var rolled []*dice.Die
+	eachDie(groups, func(d *dice.Die) {
+		rolled = append(rolled, d)
+	})
+	if len(rolled) == 0 || len(rolled) > maxImageDice {
+		return nil, nil
+	}
+
+	cols := min(len(rolled), imageMaxColumns)
+	rows := (len(rolled) + cols - 1) / cols
+	img := image.NewRGBA(image.Rect(0, 0, cols*imageDieCell+2*imagePadding, rows*imageDieCell+2*imagePadding))
+	for n, d := range rolled {
+		cx := imagePadding + (n%cols)*imageDieCell + imageDieCell/2
+		cy := imagePadding + (n/cols)*imageDieCell + imageDieCell/2
+		fill, text := dieColors(ctx, d)
+		shape := dieShape(d)
+		fillPolygon(img, shape, float64(cx), float64(cy), imageDieRadius+3, shade(fill, imageDieEdgeShade))
+		fillPolygon(img, shape, float64(cx), float64(cy), imageDieRadius, fill)
+		// triangles look centered a little below their middle
+		if d.Type != dice.TypeFudge && d.Size == 4 {
+			cy += imageDieRadius / 6
+		}
+		drawText(img, dieLabel(d), cx, cy, text)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// diceImageFile renders the dice of a roll response as a file attachment, or
+// returns nil if there's nothing to render.
+func diceImageFile(ctx context.Context, res *Response) *discordgo.File {
+	if res == nil {
+		return nil
+	}
+	data, err := RenderDiceImage(ctx, res.Groups())
+	if err != nil {
+		logger.Error("error rendering dice image", zap.Error(err))
+		return nil
+	}
+	if data == nil {
+		return nil
+	}
+	return &discordgo.File{
+		Name:        "dice.png",
+		ContentType: "image/png",
+		Reader:      bytes.NewReader(data),
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/travis-g/dice"
+)
+
+func Test_dieLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		die  *dice.Die
+		want string
+	}{
+		{name: "d20", die: &dice.Die{Size: 20, Result: dice.NewResult(17)}, want: "17"},
+		{name: "d100", die: &dice.Die{Size: 100, Result: dice.NewResult(100)}, want: "100"},
+		{name: "fudge plus", die: &dice.Die{Type: dice.TypeFudge, Size: 1, Result: dice.NewResult(1)}, want: "+"},
+		{name: "fudge minus", die: &dice.Die{Type: dice.TypeFudge, Size: 1, Result: dice.NewResult(-1)}, want: "-"},
+		{name: "fudge blank", die: &dice.Die{Type: dice.TypeFudge, Size: 1, Result: dice.NewResult(0)}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dieLabel(tt.die); got != tt.want {
+				t.Errorf("dieLabel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_dieColors(t *testing.T) {
+	ctx := context.Background()
+	dropped := &dice.Die{Size: 20, Result: dice.NewResult(20)}
+	dropped.Drop(ctx, true)
+	tests := []struct {
+		name string
+		die  *dice.Die
+		want color.RGBA
+	}{
+		{name: "normal", die: &dice.Die{Size: 20, Result: dice.NewResult(12)}, want: imageDieFill},
+		{name: "crit", die: &dice.Die{Size: 20, Result: dice.NewResult(20)}, want: imageDieCritFill},
+		{name: "fumble", die: &dice.Die{Size: 6, Result: dice.NewResult(1)}, want: imageDieFumFill},
+		{name: "dropped", die: dropped, want: imageDieDropFill},
+		{name: "fudge", die: &dice.Die{Type: dice.TypeFudge, Size: 1, Result: dice.NewResult(1)}, want: imageDieFill},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := dieColors(ctx, tt.die); got != tt.want {
+				t.Errorf("dieColors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderDiceImage(t *testing.T) {
+	ctx := context.Background()
+	if data, err := RenderDiceImage(ctx, nil); err != nil || data != nil {
+		t.Fatalf("RenderDiceImage() with no dice = %v, %v", data, err)
+	}
+
+	group := &dice.RollerGroup{}
+	for _, size := range []int{4, 6, 8, 10, 12, 20, 100} {
+		group.Group = append(group.Group, &dice.Die{Size: size, Result: dice.NewResult(2)})
+	}
+	group.Group = append(group.Group, &dice.Die{Size: 20, Result: dice.NewResult(20)})
+	data, err := RenderDiceImage(ctx, []*dice.RollerGroup{group})
+	if err != nil {
+		t.Fatalf("RenderDiceImage() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("RenderDiceImage() made an invalid PNG: %v", err)
+	}
+	bounds := img.Bounds()
+	if want := 8*imageDieCell + 2*imagePadding; bounds.Dx() != want || bounds.Dy() != imageDieCell+2*imagePadding {
+		t.Errorf("RenderDiceImage() size = %v, want %dx%d", bounds.Size(), want, imageDieCell+2*imagePadding)
+	}
+	// the last die was a crit, so the edge of its face is colored
+	x := imagePadding + 7*imageDieCell + imageDieCell/2
+	y := imagePadding + imageDieCell/2 + imageDieRadius - 4
+	if got := color.RGBAModel.Convert(img.At(x, y)); got != imageDieCritFill {
+		t.Errorf("RenderDiceImage() crit color = %v, want %v", got, imageDieCritFill)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// d20Expression builds the expression for a D&D 5e d20 test. Advantage and
+// disadvantage cancel each other out.
+func d20Expression(bonus int, advantage, disadvantage bool) string {
+	expression := "d20"
+	switch {
+	case advantage && !disadvantage:
+		expression = "2d20kh1"
+	case disadvantage && !advantage:
+		expression = "2d20kl1"
+	}
+	if bonus != 0 {
+		expression += fmt.Sprintf("%+d", bonus)
+	}
+	return expression
+}
+
+// naturalD20 returns the value of the first d20 kept for a roll.
+func naturalD20(groups []*dice.RollerGroup) (natural float64, ok bool) {
+	ctx := context.Background()
+	eachDie(groups, func(d *dice.Die) {
+		if ok || d.Type != dice.TypePolyhedron || d.Size != 20 || d.IsDropped(ctx) {
+			return
+		}
+		natural, ok = d.Result.Value, true
+	})
+	return
+}
+
+var diceCountRegexp = regexp.MustCompile(`(?i)\b(\d*)d(\d+)`)
+
+// critDamage doubles the number of dice rolled in a damage expression.
+func critDamage(expression string) string {
+	return diceCountRegexp.ReplaceAllStringFunc(expression, func(match string) string {
+		parts := diceCountRegexp.FindStringSubmatch(match)
+		count := 1
+		if parts[1] != "" {
+			count, _ = strconv.Atoi(parts[1])
+		}
+		return fmt.Sprintf("%dd%s", count*2, parts[2])
+	})
+}
+
+// Outcomes of D&D 5e d20 tests.
+const (
+	OutcomeSuccess      = "Success!"
+	OutcomeFailure      = "Failure!"
+	OutcomeHit          = "Hit!"
+	OutcomeMiss         = "Miss!"
+	OutcomeCriticalHit  = "Critical hit!"
+	OutcomeCriticalMiss = "Critical miss!"
+)
+
+// d20Outcome determines the outcome of a d20 test against a DC. Attacks hit on
+// a natural 20 and miss on a natural 1 regardless of the DC. Without a DC only
+// critical attacks have an outcome.
+func d20Outcome(natural, total float64, dc int, attack bool) string {
+	switch {
+	case attack && natural == 20:
+		return OutcomeCriticalHit
+	case attack && natural == 1:
+		return OutcomeCriticalMiss
+	case dc == 0:
+		return ""
+	case attack && total >= float64(dc):
+		return OutcomeHit
+	case attack:
+		return OutcomeMiss
+	case total >= float64(dc):
+		return OutcomeSuccess
+	default:
+		return OutcomeFailure
+	}
+}
+
+// d20TestFromInteraction builds the d20 test expression and DC from a check,
+// save or attack command's options.
+func d20TestFromInteraction(i *discordgo.Interaction) (expression string, dc int) {
+	options := i.ApplicationCommandData().Options
+	var (
+		bonus                   int
+		advantage, disadvantage bool
+	)
+	if opt := getOptionByName(options, "bonus"); opt != nil {
+		bonus = int(opt.IntValue())
+	}
+	if opt := getOptionByName(options, "advantage"); opt != nil {
+		advantage = opt.BoolValue()
+	}
+	if opt := getOptionByName(options, "disadvantage"); opt != nil {
+		disadvantage = opt.BoolValue()
+	}
+	if opt := getOptionByName(options, "dc"); opt != nil {
+		dc = int(opt.IntValue())
+	}
+	return d20Expression(bonus, advantage, disadvantage), dc
+}
+
+// respondD20Test sends a d20 test's response, ephemerally if requested.
+func respondD20Test(ctx context.Context, message *Response, response *discordgo.InteractionResponse) {
+	s, i, _ := FromContext(ctx)
+	if opt := getOptionByName(i.ApplicationCommandData().Options, "secret"); opt != nil && opt.BoolValue() {
+		response.Data.Flags |= discordgo.MessageFlagsEphemeral
+	}
+	if message != nil {
+		defer CacheRoll(UserFromInteraction(i), &NamedRollInput{
+			Expression: message.Expression,
+			Label:      message.Label,
+		})
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, response); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
+
+// d20TestInterpreter interprets d20 tests against a DC, including the DC in
+// the outcome.
+func d20TestInterpreter(dc int, attack bool) ResultInterpreter {
+	return func(res *Response) string {
+		natural, _ := naturalD20(res.Groups())
+		outcome := d20Outcome(natural, res.ExpressionResult.Result, dc, attack)
+		switch {
+		case outcome == "" || dc == 0:
+			return outcome
+		case attack:
+			return fmt.Sprintf("%s (AC %d)", outcome, dc)
+		default:
+			return fmt.Sprintf("%s (DC %d)", outcome, dc)
+		}
+	}
+}
+
+// InteractionCheck rolls a D&D 5e ability check or saving throw.
+func InteractionCheck(ctx context.Context) {
+	_, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", i.ApplicationCommandData().Name}, 1)
+
+	expression, dc := d20TestFromInteraction(i)
+	ctx = WithResultInterpreter(ctx, d20TestInterpreter(dc, false))
+	message, response, err := NewRollInteractionResponseFromStringWithContext(ctx, expression)
+	if err != nil {
+		message = nil
+	}
+	respondD20Test(ctx, message, response)
+}
+
+// InteractionAttack rolls a D&D 5e attack roll, rolling damage if it hits.
+// Critical hits roll twice the damage dice.
+func InteractionAttack(ctx context.Context) {
+	_, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "attack"}, 1)
+
+	expression, dc := d20TestFromInteraction(i)
+	message, response, err := NewRollInteractionResponseFromStringWithContext(
+		WithResultInterpreter(ctx, d20TestInterpreter(dc, true)), expression)
+	if err != nil {
+		respondD20Test(ctx, nil, response)
+		return
+	}
+	natural, _ := naturalD20(message.Groups())
+	outcome := d20Outcome(natural, message.ExpressionResult.Result, dc, true)
+
+	var damage string
+	if opt := getOptionByName(i.ApplicationCommandData().Options, "damage"); opt != nil {
+		damage = strings.TrimSpace(opt.StringValue())
+	}
+	if damage == "" || outcome == OutcomeMiss || outcome == OutcomeCriticalMiss {
+		respondD20Test(ctx, message, response)
+		return
+	}
+	if outcome == OutcomeCriticalHit {
+		damage = critDamage(damage)
+	}
+	damageMessage, err := EvaluateRollInputWithContext(ctx, &NamedRollInput{
+		Expression: damage,
+		Label:      "Damage",
+	})
+	if err != nil {
+		appendResponseText(response, createFriendlyError(err).Error())
+	} else {
+		var text strings.Builder
+		executeResponseTemplate(&text, damageMessage)
+		appendResponseText(response, text.String())
+	}
+	respondD20Test(ctx, message, response)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/travis-g/dice"
+)
+
+func Test_d20Expression(t *testing.T) {
+	tests := []struct {
+		name         string
+		bonus        int
+		advantage    bool
+		disadvantage bool
+		want         string
+	}{
+		{name: "flat", want: "d20"},
+		{name: "bonus", bonus: 5, want: "d20+5"},
+		{name: "penalty", bonus: -1, want: "d20-1"},
+		{name: "advantage", bonus: 3, advantage: true, want: "2d20kh1+3"},
+		{name: "disadvantage", disadvantage: true, want: "2d20kl1"},
+		{name: "both", bonus: 2, advantage: true, disadvantage: true, want: "d20+2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d20Expression(tt.bonus, tt.advantage, tt.disadvantage); got != tt.want {
+				t.Errorf("d20Expression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_critDamage(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{expression: "1d8+3", want: "2d8+3"},
+		{expression: "d6", want: "2d6"},
+		{expression: "2d6 + 1d4 + 2", want: "4d6 + 2d4 + 2"},
+		{expression: "4d6d1", want: "8d6d1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			if got := critDamage(tt.expression); got != tt.want {
+				t.Errorf("critDamage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_d20Outcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		natural float64
+		total   float64
+		dc      int
+		attack  bool
+		want    string
+	}{
+		{name: "check success", natural: 12, total: 15, dc: 15, want: OutcomeSuccess},
+		{name: "check failure", natural: 12, total: 14, dc: 15, want: OutcomeFailure},
+		{name: "check natural 20", natural: 20, total: 22, dc: 25, want: OutcomeFailure},
+		{name: "check no dc", natural: 20, total: 22, want: ""},
+		{name: "hit", natural: 10, total: 15, dc: 15, attack: true, want: OutcomeHit},
+		{name: "miss", natural: 10, total: 14, dc: 15, attack: true, want: OutcomeMiss},
+		{name: "critical hit", natural: 20, total: 21, dc: 25, attack: true, want: OutcomeCriticalHit},
+		{name: "critical miss", natural: 1, total: 20, dc: 15, attack: true, want: OutcomeCriticalMiss},
+		{name: "attack no dc", natural: 12, total: 17, attack: true, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d20Outcome(tt.natural, tt.total, tt.dc, tt.attack); got != tt.want {
+				t.Errorf("d20Outcome() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_naturalD20(t *testing.T) {
+	dropped := testRollerGroup(20, 3, 17)
+	dropped.Group[0].Drop(context.Background(), true)
+	tests := []struct {
+		name   string
+		groups []*dice.RollerGroup
+		want   float64
+		wantOk bool
+	}{
+		{name: "none", groups: []*dice.RollerGroup{testRollerGroup(6, 4)}},
+		{name: "single", groups: []*dice.RollerGroup{testRollerGroup(20, 14)}, want: 14, wantOk: true},
+		{name: "advantage", groups: []*dice.RollerGroup{dropped}, want: 17, wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := naturalD20(tt.groups)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("naturalD20() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
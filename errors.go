@@ -20,6 +20,8 @@ var (
 	ErrTokenTransition     = errors.New("token transition error")
 	ErrTooManyDice         = errors.New("too many dice")
 	ErrNotImplemented      = errors.New("not implemented")
+	ErrInvalidPool         = errors.New("invalid dice pool")
+	ErrUnknownDie          = errors.New("unknown die")
 )
 
 var (
@@ -31,6 +33,9 @@ var (
 
 func createFriendlyError(err error) error {
 	logger.Debug("error", zap.Error(err))
+	if errors.Is(err, ErrUnknownDie) {
+		return fmt.Errorf("That roll uses a die that hasn't been defined. Define it with %s first!", CommandMention("dice", "define"))
+	}
 	switch err {
 	case dice.ErrInvalidExpression:
 		return fmt.Errorf("I can't evaluate that expression. Is that roll valid?")
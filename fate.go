@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// fateLadder is the Fate ladder of adjectives, from -2 to +8.
+var fateLadder = []string{
+	"Terrible",
+	"Poor",
+	"Mediocre",
+	"Average",
+	"Fair",
+	"Good",
+	"Great",
+	"Superb",
+	"Fantastic",
+	"Epic",
+	"Legendary",
+}
+
+// fateLadderOffset is the index of Mediocre (+0) on the ladder.
+const fateLadderOffset = 2
+
+// FateRung returns the Fate ladder rung of a result, ex. "+3 Good". Results
+// beyond the ends of the ladder use the nearest adjective.
+func FateRung(result int) string {
+	i := min(max(result+fateLadderOffset, 0), len(fateLadder)-1)
+	return fmt.Sprintf("%+d %s", result, fateLadder[i])
+}
+
+// Fate outcomes of a roll against opposition.
+const (
+	FateFail             = "Fail"
+	FateTie              = "Tie"
+	FateSucceed          = "Succeed"
+	FateSucceedWithStyle = "Succeed with style"
+)
+
+// fateOutcome determines the outcome of a roll against opposition by its
+// shifts.
+func fateOutcome(shifts int) string {
+	switch {
+	case shifts < 0:
+		return FateFail
+	case shifts == 0:
+		return FateTie
+	case shifts < 3:
+		return FateSucceed
+	default:
+		return FateSucceedWithStyle
+	}
+}
+
+// isFateRoll returns whether every die rolled is a Fate die.
+func isFateRoll(groups []*dice.RollerGroup) bool {
+	fate, other := 0, 0
+	eachDie(groups, func(d *dice.Die) {
+		if d.Type == dice.TypeFudge {
+			fate++
+		} else {
+			other++
+		}
+	})
+	return fate > 0 && other == 0
+}
+
+// fateSymbol renders a Fate die's value as a symbol.
+func fateSymbol(value float64) string {
+	switch {
+	case value > 0:
+		return "+"
+	case value < 0:
+		return "-"
+	default:
+		return "0"
+	}
+}
+
+// rollFate rolls Fate dice like "4dF", showing each die as a symbol in the
+// rolled expression.
+func rollFate(ctx context.Context, match []string) (*RolledNotation, error) {
+	count := 1
+	if match[1] != "" {
+		count, _ = strconv.Atoi(match[1])
+	}
+	n := &RolledNotation{Group: &dice.RollerGroup{}}
+	symbols := make([]string, 0, min(count, maxChainLength))
+	for i := 0; i < count; i++ {
+		die := &dice.Die{Type: dice.TypeFudge, Size: 1}
+		if err := die.Roll(ctx); err != nil {
+			return nil, err
+		}
+		addRoller(n.Group, die)
+		n.Value += die.Result.Value
+		symbols = append(symbols, fateSymbol(die.Result.Value))
+	}
+	n.rolled = strings.Join(symbols, " ")
+	return n, nil
+}
+
+// fateLadderInterpreter interprets a roll's result on the Fate ladder.
+func fateLadderInterpreter(res *Response) string {
+	return FateRung(int(res.ExpressionResult.Result))
+}
+
+// fateOppositionInterpreter interprets a roll against opposition, including
+// the shifts gained.
+func fateOppositionInterpreter(opposition int) ResultInterpreter {
+	return func(res *Response) string {
+		result := int(res.ExpressionResult.Result)
+		shifts := result - opposition
+		outcome := fmt.Sprintf("%s vs %s: %s", FateRung(result), FateRung(opposition), fateOutcome(shifts))
+		if shifts > 0 {
+			outcome += fmt.Sprintf(" (%s)", pluralize(shifts, "shift", "shifts"))
+		}
+		return outcome
+	}
+}
+
+// InteractionFate rolls 4dF with a skill, optionally against opposition.
+func InteractionFate(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "fate"}, 1)
+
+	options := i.ApplicationCommandData().Options
+	var skill int
+	if opt := getOptionByName(options, "skill"); opt != nil {
+		skill = int(opt.IntValue())
+	}
+	interpret := fateLadderInterpreter
+	if opt := getOptionByName(options, "vs"); opt != nil {
+		interpret = fateOppositionInterpreter(int(opt.IntValue()))
+	}
+	expression := "4dF"
+	if skill != 0 {
+		expression += fmt.Sprintf("%+d", skill)
+	}
+
+	message, response, err := NewRollInteractionResponseFromStringWithContext(
+		WithResultInterpreter(ctx, interpret), expression)
+	if opt := getOptionByName(options, "secret"); opt != nil && opt.BoolValue() {
+		response.Data.Flags |= discordgo.MessageFlagsEphemeral
+	}
+	if err == nil {
+		defer CacheRoll(UserFromInteraction(i), &NamedRollInput{
+			Expression: message.Expression,
+			Label:      message.Label,
+		})
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, response); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
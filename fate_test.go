@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/travis-g/dice"
+	"github.com/travis-g/dice/math"
+)
+
+func testFateGroup(values ...float64) *dice.RollerGroup {
+	group := &dice.RollerGroup{}
+	for _, v := range values {
+		group.Group = append(group.Group, &dice.Die{Type: dice.TypeFudge, Size: 1, Result: dice.NewResult(v)})
+	}
+	return group
+}
+
+func TestFateRung(t *testing.T) {
+	tests := []struct {
+		result int
+		want   string
+	}{
+		{result: 3, want: "+3 Good"},
+		{result: 0, want: "+0 Mediocre"},
+		{result: -2, want: "-2 Terrible"},
+		{result: -4, want: "-4 Terrible"},
+		{result: 8, want: "+8 Legendary"},
+		{result: 10, want: "+10 Legendary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := FateRung(tt.result); got != tt.want {
+				t.Errorf("FateRung() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_fateOppositionInterpreter(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     float64
+		opposition int
+		want       string
+	}{
+		{name: "fail", result: 1, opposition: 2, want: "+1 Average vs +2 Fair: Fail"},
+		{name: "tie", result: 2, opposition: 2, want: "+2 Fair vs +2 Fair: Tie"},
+		{name: "succeed", result: 3, opposition: 2, want: "+3 Good vs +2 Fair: Succeed (1 shift)"},
+		{name: "style", result: 5, opposition: 2, want: "+5 Superb vs +2 Fair: Succeed with style (3 shifts)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &Response{ExpressionResult: &math.ExpressionResult{Result: tt.result}}
+			if got := fateOppositionInterpreter(tt.opposition)(res); got != tt.want {
+				t.Errorf("fateOppositionInterpreter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isFateRoll(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []*dice.RollerGroup
+		want   bool
+	}{
+		{name: "none", groups: nil, want: false},
+		{name: "fate", groups: []*dice.RollerGroup{testFateGroup(1, 0, -1, 1)}, want: true},
+		{name: "mixed", groups: []*dice.RollerGroup{testFateGroup(1), testRollerGroup(6, 3)}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isFateRoll(tt.groups); got != tt.want {
+				t.Errorf("isFateRoll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_markdownGroup_fate(t *testing.T) {
+	want := "+, 0, -, +"
+	if got := markdownGroup(context.Background(), testFateGroup(1, 0, -1, 1), ExplodeNone, nil); got != want {
+		t.Errorf("markdownGroup() = %v, want %v", got, want)
+	}
+}
+
+func Test_evaluateRoll_fate(t *testing.T) {
+	res, notations, err := evaluateRoll(context.Background(), "4dF+2dF+1")
+	if err != nil {
+		t.Fatalf("evaluateRoll() error = %v", err)
+	}
+	if len(notations) != 2 || !isFateRoll([]*dice.RollerGroup{notations[0].Group, notations[1].Group}) {
+		t.Fatalf("evaluateRoll() notations = %v, want 2 Fate notations", notations)
+	}
+	want := "(" + notations[0].rolled + ")+(" + notations[1].rolled + ")+1"
+	if res.Rolled != want {
+		t.Errorf("evaluateRoll() rolled = %q, want %q", res.Rolled, want)
+	}
+	if got := notations[0].Value + notations[1].Value + 1; res.Result != got {
+		t.Errorf("evaluateRoll() result = %v, want %v", res.Result, got)
+	}
+	if strings.ContainsAny(notations[0].rolled, "123456789") {
+		t.Errorf("evaluateRoll() rolled %q, want Fate symbols", notations[0].rolled)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// Forged in the Dark action roll outcomes.
+const (
+	FitdCritical = "Critical success"
+	FitdSuccess  = "Full success"
+	FitdPartial  = "Partial success"
+	FitdFailure  = "Failure"
+)
+
+// fitdMaxDice is the most dice that can be rolled for an action roll.
+const fitdMaxDice = 6
+
+// fitdExpression builds the expression of an action roll for a dice pool.
+// Pools of zero dice roll 2d6 and keep the lowest.
+func fitdExpression(pool int) string {
+	if pool < 1 {
+		return "2d6kl1"
+	}
+	return fmt.Sprintf("%dd6kh1", pool)
+}
+
+// fitdOutcome determines the outcome of an action roll. Multiple 6s are a
+// critical, except for zero-dice pools where only the kept die counts.
+func fitdOutcome(groups []*dice.RollerGroup, zero bool) string {
+	ctx := context.Background()
+	var highest float64
+	sixes := 0
+	eachDie(groups, func(d *dice.Die) {
+		if zero && d.IsDropped(ctx) {
+			return
+		}
+		if d.Result.Value == 6 {
+			sixes++
+		}
+		highest = max(highest, d.Result.Value)
+	})
+	switch {
+	case sixes > 1:
+		return FitdCritical
+	case highest == 6:
+		return FitdSuccess
+	case highest >= 4:
+		return FitdPartial
+	default:
+		return FitdFailure
+	}
+}
+
+// fitdInterpreter interprets action rolls for a pool, including the roll's
+// position and effect in the outcome if set.
+func fitdInterpreter(pool int, position, effect string) ResultInterpreter {
+	return func(res *Response) string {
+		outcome := fitdOutcome(res.Groups(), pool < 1)
+		var details []string
+		if position != "" {
+			details = append(details, position)
+		}
+		if effect != "" {
+			details = append(details, effect+" effect")
+		}
+		if len(details) > 0 {
+			outcome += " (" + strings.Join(details, ", ") + ")"
+		}
+		return outcome
+	}
+}
+
+// makeFitdPadComponents creates a button pad of action rolls for each dice
+// pool size.
+func makeFitdPadComponents() []discordgo.MessageComponent {
+	maxCols := 5
+	var rows []discordgo.MessageComponent
+	var buttons []discordgo.MessageComponent
+	for pool := 0; pool <= fitdMaxDice; pool++ {
+		style := discordgo.SecondaryButton
+		if pool == 2 {
+			style = discordgo.PrimaryButton
+		}
+		buttons = append(buttons, discordgo.Button{
+			Label:    fmt.Sprintf("%dd", pool),
+			Style:    style,
+			CustomID: newComponentID("fitd", strconv.Itoa(pool)),
+		})
+		if len(buttons) == maxCols || pool == fitdMaxDice {
+			rows = append(rows, discordgo.ActionsRow{Components: buttons})
+			buttons = nil
+		}
+	}
+	return rows
+}
+
+// InteractionFitd makes a Forged in the Dark action roll.
+func InteractionFitd(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "fitd"}, 1)
+
+	options := i.ApplicationCommandData().Options
+	pool := int(mustGetOptionByName(options, "dice").IntValue())
+	var position, effect string
+	if opt := getOptionByName(options, "position"); opt != nil {
+		position = opt.StringValue()
+	}
+	if opt := getOptionByName(options, "effect"); opt != nil {
+		effect = opt.StringValue()
+	}
+
+	message, response, err := NewRollInteractionResponseFromStringWithContext(
+		WithResultInterpreter(ctx, fitdInterpreter(pool, position, effect)), fitdExpression(pool))
+	if opt := getOptionByName(options, "secret"); opt != nil && opt.BoolValue() {
+		response.Data.Flags |= discordgo.MessageFlagsEphemeral
+	}
+	if err == nil {
+		defer CacheRoll(UserFromInteraction(i), &NamedRollInput{
+			Expression: message.Expression,
+			Label:      message.Label,
+		})
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, response); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
+
+// InteractionFitdComponent handles presses of the FitD pad's buttons.
+func InteractionFitdComponent(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "fitd"}, 1)
+	_, args := parseComponentID(i.MessageComponentData().CustomID)
+	if len(args) == 0 {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+		return
+	}
+	pool, err := strconv.Atoi(args[0])
+	if err != nil || pool < 0 || pool > fitdMaxDice {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+		return
+	}
+	RollMacroInteraction(WithResultInterpreter(ctx, fitdInterpreter(pool, "", "")), fitdExpression(pool))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/travis-g/dice"
+)
+
+func Test_fitdExpression(t *testing.T) {
+	tests := []struct {
+		pool int
+		want string
+	}{
+		{pool: 0, want: "2d6kl1"},
+		{pool: 1, want: "1d6kh1"},
+		{pool: 4, want: "4d6kh1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := fitdExpression(tt.pool); got != tt.want {
+				t.Errorf("fitdExpression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_fitdOutcome(t *testing.T) {
+	zero := testRollerGroup(6, 6, 6)
+	zero.Group[1].Drop(context.Background(), true)
+	tests := []struct {
+		name  string
+		group *dice.RollerGroup
+		zero  bool
+		want  string
+	}{
+		{name: "critical", group: testRollerGroup(6, 6, 2, 6), want: FitdCritical},
+		{name: "success", group: testRollerGroup(6, 3, 6), want: FitdSuccess},
+		{name: "partial", group: testRollerGroup(6, 4, 1, 5), want: FitdPartial},
+		{name: "failure", group: testRollerGroup(6, 3, 1), want: FitdFailure},
+		{name: "zero dice", group: zero, zero: true, want: FitdSuccess},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fitdOutcome([]*dice.RollerGroup{tt.group}, tt.zero); got != tt.want {
+				t.Errorf("fitdOutcome() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_makeFitdPadComponents(t *testing.T) {
+	buttons := 0
+	for _, row := range makeFitdPadComponents() {
+		n := len(row.(discordgo.ActionsRow).Components)
+		if n > 5 {
+			t.Errorf("row has %d buttons, want at most 5", n)
+		}
+		buttons += n
+	}
+	if buttons != fitdMaxDice+1 {
+		t.Errorf("pad has %d buttons, want %d", buttons, fitdMaxDice+1)
+	}
+}
@@ -0,0 +1,591 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Limits of group rolls. Group rolls close before their interaction tokens
+// expire so their messages can still be edited.
+const (
+	defaultGroupRollTimeout = 2 * time.Minute
+	minGroupRollTimeout     = 30 * time.Second
+	maxGroupRollTimeout     = 10 * time.Minute
+	maxGroupRollModifiers   = 5
+	maxGroupRollers         = 25
+
+	// groupRollKeep is how long a group roll is kept past closing for the
+	// scheduler to close it, within its interaction token's 15 minutes.
+	groupRollKeep = 4 * time.Minute
+)
+
+// groupRollModifierRegexp matches per-user modifiers in group roll
+// expressions, ex. "@WIS".
+var groupRollModifierRegexp = regexp.MustCompile(`@([A-Za-z][A-Za-z0-9_]*)`)
+
+// ErrInvalidGroupRollTimeout is returned for group roll timeouts that can't be
+// used.
+var ErrInvalidGroupRollTimeout = fmt.Errorf("Group rolls need a timeout between %s and %s, like `2m`.", minGroupRollTimeout, maxGroupRollTimeout)
+
+// A GroupRoll is a check made by everyone in a channel that rolls it before it
+// closes.
+type GroupRoll struct {
+	ID         string `json:"id"`
+	Owner      string `json:"owner"`
+	Expression string `json:"expression"`
+	Label      string `json:"label,omitempty"`
+	DC         int    `json:"dc"`
+	Closes     int64  `json:"closes"`
+
+	// the interaction that posted the group roll, to edit it when it closes
+	AppID string `json:"app_id"`
+	Token string `json:"token"`
+}
+
+// A GroupRollEntry is a user's roll of a group roll.
+type GroupRollEntry struct {
+	User       string  `json:"user"`
+	Expression string  `json:"expression"`
+	Rolled     string  `json:"rolled"`
+	Result     float64 `json:"result"`
+	Time       int64   `json:"time"`
+}
+
+// parseGroupRollTimeout parses the timeout of a group roll, ex. "2m".
+func parseGroupRollTimeout(s string) (time.Duration, error) {
+	if strings.TrimSpace(s) == "" {
+		return defaultGroupRollTimeout, nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil || d < minGroupRollTimeout || d > maxGroupRollTimeout {
+		return 0, ErrInvalidGroupRollTimeout
+	}
+	return d, nil
+}
+
+// groupRollModifiers returns the distinct names of the per-user modifiers of
+// an expression, in upper case.
+func groupRollModifiers(expression string) []string {
+	var names []string
+	for _, match := range groupRollModifierRegexp.FindAllStringSubmatch(expression, -1) {
+		name := strings.ToUpper(match[1])
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// substituteModifiers replaces the per-user modifiers of an expression with
+// their values, returning the names of modifiers without one.
+func substituteModifiers(expression string, modifiers map[string]int) (string, []string) {
+	var missing []string
+	expression = groupRollModifierRegexp.ReplaceAllStringFunc(expression, func(match string) string {
+		name := strings.ToUpper(match[1:])
+		v, ok := modifiers[name]
+		if !ok {
+			if !slices.Contains(missing, name) {
+				missing = append(missing, name)
+			}
+			return match
+		}
+		return fmt.Sprintf("(%d)", v)
+	})
+	return expression, missing
+}
+
+// groupCheckPassed returns whether a group check succeeds: at least half of the
+// group has to pass.
+func groupCheckPassed(passes, total int) bool {
+	return total > 0 && passes*2 >= total
+}
+
+// GetModifiers returns a user's saved per-user modifiers.
+func GetModifiers(ctx context.Context, uid string) map[string]int {
+	modifiers := make(map[string]int)
+	data, err := DiceGolem.Cache.Redis.HGetAll(ctx, fmt.Sprintf(KeyCacheUserModifiersFmt, uid)).Result()
+	if err != nil {
+		return modifiers
+	}
+	for name, v := range data {
+		if n, err := strconv.Atoi(v); err == nil {
+			modifiers[name] = n
+		}
+	}
+	return modifiers
+}
+
+// SetModifiers saves per-user modifiers for a user.
+func SetModifiers(ctx context.Context, uid string, modifiers map[string]int) error {
+	key := fmt.Sprintf(KeyCacheUserModifiersFmt, uid)
+	_, err := DiceGolem.Cache.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for name, v := range modifiers {
+			pipe.HSet(ctx, key, name, strconv.Itoa(v))
+		}
+		pipe.Expire(ctx, key, DiceGolem.DataTTL)
+		return nil
+	})
+	return err
+}
+
+// GetGroupRoll returns an open group roll and its rolls so far, in the order
+// they were made. If take is set the group roll is closed, so only one caller
+// can summarize it.
+func GetGroupRoll(ctx context.Context, id string, take bool) (*GroupRoll, []*GroupRollEntry, error) {
+	key := fmt.Sprintf(KeyGroupRollFmt, id)
+	cmd := DiceGolem.Cache.Redis.Get
+	if take {
+		cmd = DiceGolem.Cache.Redis.GetDel
+	}
+	data, err := cmd(ctx, key).Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	group := new(GroupRoll)
+	if err := json.Unmarshal(data, group); err != nil {
+		return nil, nil, err
+	}
+	rolls, err := DiceGolem.Cache.Redis.HGetAll(ctx, fmt.Sprintf(KeyGroupRollEntriesFmt, id)).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	entries := make([]*GroupRollEntry, 0, len(rolls))
+	for _, v := range rolls {
+		entry := new(GroupRollEntry)
+		if err := json.Unmarshal([]byte(v), entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b *GroupRollEntry) int { return cmp.Compare(a.Time, b.Time) })
+	if take {
+		DiceGolem.Cache.Redis.Del(ctx, fmt.Sprintf(KeyGroupRollEntriesFmt, id))
+		DiceGolem.Cache.Redis.ZRem(ctx, KeyGroupRollDue, id)
+	}
+	return group, entries, nil
+}
+
+// SetGroupRoll saves a new group roll and queues it to be closed by the
+// scheduler.
+func SetGroupRoll(ctx context.Context, group *GroupRoll) error {
+	data, err := json.Marshal(group)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(time.Unix(group.Closes, 0)) + groupRollKeep
+	_, err = DiceGolem.Cache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, fmt.Sprintf(KeyGroupRollFmt, group.ID), data, ttl)
+		pipe.ZAdd(ctx, KeyGroupRollDue, redis.Z{Score: float64(group.Closes), Member: group.ID})
+		return nil
+	})
+	return err
+}
+
+// AddGroupRollEntry records a user's roll of a group roll, returning false if
+// they already rolled.
+func AddGroupRollEntry(ctx context.Context, group *GroupRoll, entry *GroupRollEntry) (bool, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return false, err
+	}
+	key := fmt.Sprintf(KeyGroupRollEntriesFmt, group.ID)
+	var added *redis.BoolCmd
+	_, err = DiceGolem.Cache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		added = pipe.HSetNX(ctx, key, entry.User, string(data))
+		pipe.ExpireAt(ctx, key, time.Unix(group.Closes, 0).Add(groupRollKeep))
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return added.Val(), nil
+}
+
+// markdownGroupRoll renders a group roll and its rolls. Closed group rolls are
+// summarized with the group check's outcome.
+func markdownGroupRoll(group *GroupRoll, entries []*GroupRollEntry, closed bool) string {
+	var b strings.Builder
+	b.WriteString("## Group Check")
+	if group.Label != "" {
+		fmt.Fprintf(&b, ": %s", group.Label)
+	}
+	fmt.Fprintf(&b, " (DC %d)\n", group.DC)
+	fmt.Fprintf(&b, "Everyone rolls `%s`", group.Expression)
+	if !closed {
+		fmt.Fprintf(&b, " · closes <t:%d:R>", group.Closes)
+	}
+	b.WriteString("\n")
+	var passes int
+	for _, entry := range entries {
+		mark := "❌"
+		if entry.Result >= float64(group.DC) {
+			mark = "✅"
+			passes++
+		}
+		// only totals are shown so every roller fits in a message
+		fmt.Fprintf(&b, "%s <@%s> **%v**\n", mark, entry.User, entry.Result)
+	}
+	if !closed {
+		return truncString(b.String(), 2000)
+	}
+	switch {
+	case len(entries) == 0:
+		b.WriteString("Nobody rolled.")
+	case groupCheckPassed(passes, len(entries)):
+		fmt.Fprintf(&b, "**The group succeeds!** %d of %d passed.", passes, len(entries))
+	default:
+		fmt.Fprintf(&b, "**The group fails.** %d of %d passed.", passes, len(entries))
+	}
+	return truncString(b.String(), 2000)
+}
+
+// makeGroupRollComponents creates the buttons of an open group roll.
+func makeGroupRollComponents(group *GroupRoll) []discordgo.MessageComponent {
+	buttons := []discordgo.MessageComponent{
+		discordgo.Button{
+			Label:    "Roll",
+			Style:    discordgo.PrimaryButton,
+			Emoji:    &discordgo.ComponentEmoji{Name: "🎲"},
+			CustomID: newComponentID("grouproll", "roll", group.ID),
+		},
+	}
+	if len(groupRollModifiers(group.Expression)) > 0 {
+		buttons = append(buttons, discordgo.Button{
+			Label:    "Set modifiers",
+			Style:    discordgo.SecondaryButton,
+			CustomID: newComponentID("grouproll", "modifiers", group.ID),
+		})
+	}
+	buttons = append(buttons, discordgo.Button{
+		Label:    "Close",
+		Style:    discordgo.SecondaryButton,
+		CustomID: newComponentID("grouproll", "close", group.ID),
+	})
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: buttons},
+	}
+}
+
+// makeGroupRollModal creates a modal for a user to enter their values of
+// per-user modifiers.
+func makeGroupRollModal(group *GroupRoll, names []string, modifiers map[string]int) *discordgo.InteractionResponse {
+	components := make([]discordgo.MessageComponent, 0, len(names))
+	for _, name := range names {
+		var value string
+		if v, ok := modifiers[name]; ok {
+			value = strconv.Itoa(v)
+		}
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    name,
+					Label:       "@" + name,
+					Style:       discordgo.TextInputShort,
+					Placeholder: "+3",
+					Value:       value,
+					Required:    true,
+					MaxLength:   4,
+				},
+			},
+		})
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID:   newComponentID("modal_grouproll", group.ID),
+			Title:      "Your Modifiers",
+			Components: components,
+		},
+	}
+}
+
+// newGroupRollResponse updates a group roll's message with its rolls.
+func newGroupRollResponse(group *GroupRoll, entries []*GroupRollEntry, closed bool) *discordgo.InteractionResponse {
+	components := []discordgo.MessageComponent{}
+	if !closed {
+		components = makeGroupRollComponents(group)
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    markdownGroupRoll(group, entries, closed),
+			Components: components,
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Users: []string{},
+			},
+		},
+	}
+}
+
+// groupRollExpired responds that a group roll has already closed.
+func groupRollExpired(ctx context.Context, err error) {
+	s, i, _ := FromContext(ctx)
+	if !errors.Is(err, redis.Nil) {
+		logger.Error("error getting group roll", zap.Error(err))
+	}
+	MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry! That group roll has already closed."))
+}
+
+// InteractionGroupRoll starts a group roll that everyone in the channel can
+// make until it closes.
+func InteractionGroupRoll(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "grouproll"}, 1)
+
+	if DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	group := &GroupRoll{
+		ID:         i.ID,
+		Owner:      UserFromInteraction(i).ID,
+		AppID:      i.AppID,
+		Token:      i.Token,
+		Expression: strings.TrimSpace(mustGetOptionByName(options, "expression").StringValue()),
+		DC:         int(mustGetOptionByName(options, "dc").IntValue()),
+	}
+	if opt := getOptionByName(options, "label"); opt != nil {
+		group.Label = opt.StringValue()
+	}
+	var timeout string
+	if opt := getOptionByName(options, "timeout"); opt != nil {
+		timeout = opt.StringValue()
+	}
+	d, err := parseGroupRollTimeout(timeout)
+	if err != nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(err.Error()))
+		return
+	}
+	group.Closes = time.Now().Add(d).Unix()
+
+	names := groupRollModifiers(group.Expression)
+	if len(names) > maxGroupRollModifiers {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+			fmt.Sprintf("Group rolls can only use up to %d modifiers.", maxGroupRollModifiers)))
+		return
+	}
+	// check the expression rolls before anyone tries it
+	zeroes := make(map[string]int, len(names))
+	for _, name := range names {
+		zeroes[name] = 0
+	}
+	expression, _ := substituteModifiers(group.Expression, zeroes)
+	if _, _, err := evaluateRoll(ctx, expression); err != nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(createFriendlyError(err).Error()))
+		return
+	}
+
+	if err := SetGroupRoll(ctx, group); err != nil {
+		logger.Error("error saving group roll", zap.Error(err))
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+	response := newGroupRollResponse(group, nil, false)
+	response.Type = discordgo.InteractionResponseChannelMessageWithSource
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, response); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
+
+// closeDueGroupRolls closes the group rolls that timed out by now and updates
+// their messages with the outcome. Taking a group roll is atomic, so only one
+// process closes each.
+func (b *Bot) closeDueGroupRolls(ctx context.Context, now time.Time) {
+	due, err := b.Cache.Redis.ZRangeByScore(ctx, KeyGroupRollDue, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.Unix(), 10),
+	}).Result()
+	if err != nil {
+		logger.Error("error getting group rolls", zap.Error(err))
+		return
+	}
+	for _, id := range due {
+		group, entries, err := GetGroupRoll(ctx, id, true)
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				// closed early, or expired before it could be closed
+				b.Cache.Redis.ZRem(ctx, KeyGroupRollDue, id)
+			} else {
+				logger.Error("error closing group roll", zap.String("id", id), zap.Error(err))
+			}
+			continue
+		}
+		interaction := &discordgo.Interaction{AppID: group.AppID, Token: group.Token}
+		if _, err := b.Sessions[0].InteractionResponseEdit(interaction, &discordgo.WebhookEdit{
+			Content:    Ptr(markdownGroupRoll(group, entries, true)),
+			Components: &[]discordgo.MessageComponent{},
+		}); err != nil {
+			logger.Error("error closing group roll", zap.String("id", id), zap.Error(err))
+		}
+	}
+}
+
+// InteractionGroupRollComponent handles the buttons of a group roll.
+func InteractionGroupRollComponent(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "grouproll"}, 1)
+	_, args := parseComponentID(i.MessageComponentData().CustomID)
+	if len(args) < 2 || DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+		return
+	}
+	uid := UserFromInteraction(i).ID
+	group, entries, err := GetGroupRoll(ctx, args[1], false)
+	if err != nil {
+		groupRollExpired(ctx, err)
+		return
+	}
+
+	switch args[0] {
+	case "close":
+		if uid != group.Owner {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry! Only whoever started the group roll can close it."))
+			return
+		}
+		closeGroupRoll(ctx, group.ID)
+	case "modifiers":
+		names := groupRollModifiers(group.Expression)
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, makeGroupRollModal(group, names, GetModifiers(ctx, uid))); err != nil {
+			logger.Error("error sending modal", zap.Error(err))
+		}
+	case "roll":
+		if time.Now().Unix() >= group.Closes {
+			closeGroupRoll(ctx, group.ID)
+			return
+		}
+		if slices.ContainsFunc(entries, func(e *GroupRollEntry) bool { return e.User == uid }) {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("You've already rolled!"))
+			return
+		}
+		if len(entries) >= maxGroupRollers {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+				fmt.Sprintf("Sorry! Group rolls can only have up to %d rollers.", maxGroupRollers)))
+			return
+		}
+		modifiers := GetModifiers(ctx, uid)
+		if _, missing := substituteModifiers(group.Expression, modifiers); len(missing) > 0 {
+			if err := MeasureInteractionRespond(s.InteractionRespond, i, makeGroupRollModal(group, missing, modifiers)); err != nil {
+				logger.Error("error sending modal", zap.Error(err))
+			}
+			return
+		}
+		rollGroupRoll(ctx, group, modifiers)
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+	}
+}
+
+// GroupRollModalInteraction saves the per-user modifiers entered by a user and
+// makes their roll of the group roll if they haven't yet.
+func GroupRollModalInteraction(ctx context.Context, data map[string]any) {
+	s, i, _ := FromContext(ctx)
+	_, args := parseComponentID(i.ModalSubmitData().CustomID)
+	if len(args) == 0 {
+		groupRollExpired(ctx, redis.Nil)
+		return
+	}
+	uid := UserFromInteraction(i).ID
+	modifiers := make(map[string]int, len(data))
+	for name, v := range data {
+		str, _ := v.(string)
+		n, err := strconv.Atoi(strings.TrimSpace(str))
+		if err != nil {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+				fmt.Sprintf("`@%s` needs to be a whole number, like `3` or `-1`.", name)))
+			return
+		}
+		modifiers[name] = n
+	}
+	if err := SetModifiers(ctx, uid, modifiers); err != nil {
+		logger.Error("error saving modifiers", zap.Error(err))
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	group, entries, err := GetGroupRoll(ctx, args[0], false)
+	if err != nil {
+		groupRollExpired(ctx, err)
+		return
+	}
+	if time.Now().Unix() >= group.Closes || slices.ContainsFunc(entries, func(e *GroupRollEntry) bool { return e.User == uid }) {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Saved your modifiers!"))
+		return
+	}
+	rollGroupRoll(ctx, group, GetModifiers(ctx, uid))
+}
+
+// rollGroupRoll makes the user's roll of a group roll with their modifiers and
+// updates the group roll's message.
+func rollGroupRoll(ctx context.Context, group *GroupRoll, modifiers map[string]int) {
+	s, i, _ := FromContext(ctx)
+	expression, _ := substituteModifiers(group.Expression, modifiers)
+	res, _, err := evaluateRoll(ctx, expression)
+	if err == nil && res == nil {
+		err = ErrNilExpressionResult
+	}
+	if err != nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(createFriendlyError(err).Error()))
+		return
+	}
+	entry := &GroupRollEntry{
+		User:       UserFromInteraction(i).ID,
+		Expression: expression,
+		Rolled:     res.Rolled,
+		Result:     res.Result,
+		Time:       time.Now().UnixNano(),
+	}
+	added, err := AddGroupRollEntry(ctx, group, entry)
+	if err != nil {
+		logger.Error("error saving group roll", zap.Error(err))
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+	if !added {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("You've already rolled!"))
+		return
+	}
+	// reload the rolls so concurrent rolls all show up
+	group, entries, err := GetGroupRoll(ctx, group.ID, false)
+	if err != nil {
+		groupRollExpired(ctx, err)
+		return
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, newGroupRollResponse(group, entries, false)); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+		return
+	}
+	// the group roll only shows totals, so show the roller their dice
+	if _, err := s.FollowupMessageCreate(i, false, &discordgo.WebhookParams{
+		Flags:   discordgo.MessageFlagsEphemeral,
+		Content: truncString(fmt.Sprintf("You rolled `%s`: `%s` = **%v**", entry.Expression, entry.Rolled, entry.Result), 2000),
+	}); err != nil {
+		logger.Error("error sending followup", zap.Error(err))
+	}
+}
+
+// closeGroupRoll closes a group roll and updates its message with the outcome.
+func closeGroupRoll(ctx context.Context, id string) {
+	s, i, _ := FromContext(ctx)
+	group, entries, err := GetGroupRoll(ctx, id, true)
+	if err != nil {
+		groupRollExpired(ctx, err)
+		return
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, newGroupRollResponse(group, entries, true)); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
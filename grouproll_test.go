@@ -0,0 +1,130 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_parseGroupRollTimeout(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "", want: defaultGroupRollTimeout},
+		{input: "2m", want: 2 * time.Minute},
+		{input: " 90s ", want: 90 * time.Second},
+		{input: "10m", want: 10 * time.Minute},
+		{input: "5s", wantErr: true},
+		{input: "1h", wantErr: true},
+		{input: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseGroupRollTimeout(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGroupRollTimeout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseGroupRollTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_groupRollModifiers(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       []string
+	}{
+		{expression: "1d20", want: nil},
+		{expression: "1d20+@WIS", want: []string{"WIS"}},
+		{expression: "1d20+@wis+@Prof+@WIS", want: []string{"WIS", "PROF"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			if got := groupRollModifiers(tt.expression); !slices.Equal(got, tt.want) {
+				t.Errorf("groupRollModifiers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_substituteModifiers(t *testing.T) {
+	tests := []struct {
+		name        string
+		expression  string
+		modifiers   map[string]int
+		want        string
+		wantMissing []string
+	}{
+		{name: "none", expression: "1d20+2", want: "1d20+2"},
+		{name: "positive", expression: "1d20+@WIS", modifiers: map[string]int{"WIS": 3}, want: "1d20+(3)"},
+		{name: "negative", expression: "1d20+@wis", modifiers: map[string]int{"WIS": -1}, want: "1d20+(-1)"},
+		{name: "missing", expression: "1d20+@WIS+@PROF+@PROF", modifiers: map[string]int{"WIS": 3}, want: "1d20+(3)+@PROF+@PROF", wantMissing: []string{"PROF"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := substituteModifiers(tt.expression, tt.modifiers)
+			if got != tt.want {
+				t.Errorf("substituteModifiers() = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(missing, tt.wantMissing) {
+				t.Errorf("substituteModifiers() missing = %v, want %v", missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func Test_groupCheckPassed(t *testing.T) {
+	tests := []struct {
+		passes, total int
+		want          bool
+	}{
+		{passes: 0, total: 0, want: false},
+		{passes: 1, total: 1, want: true},
+		{passes: 2, total: 4, want: true},
+		{passes: 1, total: 3, want: false},
+		{passes: 2, total: 3, want: true},
+	}
+	for _, tt := range tests {
+		if got := groupCheckPassed(tt.passes, tt.total); got != tt.want {
+			t.Errorf("groupCheckPassed(%d, %d) = %v, want %v", tt.passes, tt.total, got, tt.want)
+		}
+	}
+}
+
+func Test_markdownGroupRoll(t *testing.T) {
+	group := &GroupRoll{Expression: "1d20+@WIS", Label: "Stealth", DC: 12, Closes: 1700000000}
+	entries := []*GroupRollEntry{
+		{User: "1", Expression: "1d20+(3)", Rolled: "[14]+(3)", Result: 17},
+		{User: "2", Expression: "1d20+(0)", Rolled: "[4]+(0)", Result: 4},
+	}
+	open := markdownGroupRoll(group, entries, false)
+	if !strings.Contains(open, "<t:1700000000:R>") || !strings.Contains(open, "✅ <@1>") || !strings.Contains(open, "❌ <@2>") {
+		t.Errorf("markdownGroupRoll() = %v", open)
+	}
+	closed := markdownGroupRoll(group, entries, true)
+	if !strings.HasSuffix(closed, "**The group succeeds!** 1 of 2 passed.") {
+		t.Errorf("markdownGroupRoll() closed = %v", closed)
+	}
+
+	// a full group with long rolls still fits in a message with its summary
+	group.Expression = strings.Repeat("1d20+", 19) + "1d20"
+	entries = nil
+	for n := range maxGroupRollers {
+		entries = append(entries, &GroupRollEntry{
+			User:       strconv.Itoa(1000000000000000000 + n),
+			Expression: group.Expression,
+			Rolled:     strings.Repeat("[20]+", 19) + "[20]",
+			Result:     400,
+		})
+	}
+	full := markdownGroupRoll(group, entries, true)
+	if len(full) > 2000 || !strings.HasSuffix(full, "**The group succeeds!** 25 of 25 passed.") {
+		t.Errorf("markdownGroupRoll() full = %d characters: %v", len(full), full)
+	}
+}
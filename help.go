@@ -60,6 +60,22 @@ func makeEmbedHelp() *discordgo.MessageEmbed {
 				Name:  "Sorting Dice",
 				Value: "Sort dice of a roll with `s`.\n`s`, `sa` - sort rolls ascending\n`sd` - sort rolls descending",
 			},
+			{
+				Name:  "Exploding Dice",
+				Value: "Roll another die for each maximum roll with `!`, like `3d6!`. Compound explosions into a single die with `!!`, or penetrate with `!p` to subtract 1 from each additional die.",
+			},
+			{
+				Name:  "Targets",
+				Value: "Count successes in a dice pool by comparing each die to a target: `10d10>=8` counts dice of 8 or more. Add `!` to explode maximum rolls (`6d6!>=5`) and `f` to subtract failures (`10d10>=8f1`). If more than half the dice are 1s the roll is a glitch.",
+			},
+			{
+				Name:  "Custom Dice",
+				Value: "Define your own dice with `/dice define`, like faces `1,1,2,3,4,6` or `Hit,Hit,Miss`, then roll them by name: `3d{dwarf}`. Numbers are added up and other faces are counted.",
+			},
+			{
+				Name:  "Random Tables",
+				Value: "Create random tables from CSV with `/table create`, then roll on them with `/table roll`. Entries can roll dice like `[[2d6]] goblins` or roll on other tables with `[[table:treasure]]`.",
+			},
 		},
 	}
 }
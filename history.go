@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// historyPageSize is the number of rolls shown per page of a user's history.
+const historyPageSize = 5
+
+// HistoryEntry is a single evaluated roll within a user's roll history.
+type HistoryEntry struct {
+	Expression string `json:"e"`
+	Label      string `json:"l,omitempty"`
+	Rolled     string `json:"r"`
+	Result     string `json:"v"`
+	Channel    string `json:"c,omitempty"`
+	ID         string `json:"i,omitempty"`
+
+	// Time the roll was made, also used as the entry's score.
+	Time time.Time `json:"t"`
+}
+
+// NamedRollInput returns the entry as a roll input that can be re-rolled or
+// saved.
+func (e *HistoryEntry) NamedRollInput() *NamedRollInput {
+	return &NamedRollInput{
+		Expression: e.Expression,
+		Label:      e.Label,
+	}
+}
+
+// Matches returns whether the entry's expression or label contain the filter,
+// ignoring case.
+func (e *HistoryEntry) Matches(filter string) bool {
+	if filter == "" {
+		return true
+	}
+	filter = strings.ToLower(filter)
+	return strings.Contains(strings.ToLower(e.Expression), filter) ||
+		strings.Contains(strings.ToLower(e.Label), filter)
+}
+
+// CacheHistoryFromContext adds an evaluated roll to the history of the user
+// that made it. This should be called in a goroutine.
+func CacheHistoryFromContext(ctx context.Context, entry *HistoryEntry) {
+	// if no Redis cache, skip
+	if DiceGolem.Cache.Redis == nil {
+		return
+	}
+
+	defer recover()
+	uid, _, _ := idsFromContext(ctx)
+
+	// skip if user does not want rolls cached
+	if UserHasPreference(&discordgo.User{ID: uid}, SettingNoRecent) {
+		return
+	}
+
+	now := time.Now()
+	entry.Time = now.UTC()
+	member, err := json.Marshal(entry)
+	if err != nil {
+		logger.Error("error encoding history", zap.Error(err))
+		return
+	}
+
+	key := fmt.Sprintf(KeyCacheUserHistoryFmt, uid)
+	defer metrics.MeasureSince([]string{"redis", "cache_history"}, time.Now())
+	_, err = DiceGolem.Cache.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, redis.Z{
+			Score:  float64(now.UnixMilli()),
+			Member: member,
+		})
+		// trim history to the maximum size and drop expired entries
+		pipe.ZRemRangeByRank(ctx, key, 0, int64(-1-DiceGolem.MaxHistory))
+		pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprint(now.Add(-DiceGolem.HistoryTTL).UnixMilli()))
+		pipe.Expire(ctx, key, DiceGolem.HistoryTTL)
+		return nil
+	})
+	if err != nil {
+		logger.Error("error caching history", zap.Error(err))
+	}
+}
+
+// GetHistory returns a user's roll history from most to least recent.
+func GetHistory(ctx context.Context, u *discordgo.User) ([]*HistoryEntry, error) {
+	if DiceGolem.Cache.Redis == nil {
+		return nil, ErrNoRedisClient
+	}
+	defer metrics.MeasureSince([]string{"redis", "get_history"}, time.Now())
+	zs, err := DiceGolem.Cache.Redis.ZRevRangeWithScores(ctx, fmt.Sprintf(KeyCacheUserHistoryFmt, u.ID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return historyEntriesFromZ(zs), nil
+}
+
+// GetHistoryEntry returns an entry of a user's roll history made at a given
+// time in milliseconds, if it exists. Rolls made at the same time are ordered
+// from most to least recent, matching GetHistory.
+func GetHistoryEntry(ctx context.Context, u *discordgo.User, ms string, n int) (*HistoryEntry, error) {
+	if DiceGolem.Cache.Redis == nil {
+		return nil, ErrNoRedisClient
+	}
+	zs, err := DiceGolem.Cache.Redis.ZRevRangeByScoreWithScores(ctx, fmt.Sprintf(KeyCacheUserHistoryFmt, u.ID), &redis.ZRangeBy{
+		Min: ms,
+		Max: ms,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	entries := historyEntriesFromZ(zs)
+	if n < 0 || n >= len(entries) {
+		return nil, redis.Nil
+	}
+	return entries[n], nil
+}
+
+func historyEntriesFromZ(zs []redis.Z) []*HistoryEntry {
+	entries := make([]*HistoryEntry, 0, len(zs))
+	for _, z := range zs {
+		entry := new(HistoryEntry)
+		if err := json.Unmarshal([]byte(z.Member.(string)), entry); err != nil {
+			logger.Warn("invalid history entry", zap.Error(err))
+			continue
+		}
+		entry.Time = time.UnixMilli(int64(z.Score))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// makeHistoryComponents renders a page of history entries matching a filter as
+// Components V2 message components.
+func makeHistoryComponents(entries []*HistoryEntry, page int, filter string) []discordgo.MessageComponent {
+	matched := make([]*HistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Matches(filter) {
+			matched = append(matched, entry)
+		}
+	}
+
+	if len(matched) == 0 {
+		content := "You have no recent rolls."
+		if filter != "" {
+			content = fmt.Sprintf("You have no recent rolls matching `%s`.", filter)
+		}
+		return []discordgo.MessageComponent{
+			discordgo.TextDisplay{Content: content},
+		}
+	}
+
+	pages := (len(matched) + historyPageSize - 1) / historyPageSize
+	page = max(0, min(page, pages-1))
+	start := page * historyPageSize
+	end := min(start+historyPageSize, len(matched))
+
+	title := "## Roll History"
+	if filter != "" {
+		title += fmt.Sprintf("\n-# Filtered by `%s`", filter)
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.TextDisplay{Content: title},
+	}
+	// number entries sharing a timestamp so each gets a distinct button ID
+	seen := make(map[int64]int)
+	nth := make(map[*HistoryEntry]int, len(entries))
+	for _, entry := range entries {
+		nth[entry] = seen[entry.Time.UnixMilli()]
+		seen[entry.Time.UnixMilli()]++
+	}
+	for _, entry := range matched[start:end] {
+		var b strings.Builder
+		fmt.Fprintf(&b, "`%s`", entry.Expression)
+		if entry.Label != "" {
+			fmt.Fprintf(&b, " _%s_", entry.Label)
+		}
+		fmt.Fprintf(&b, ": `%s` = **%s**\n-# <t:%d:R>", entry.Rolled, entry.Result, entry.Time.Unix())
+		if entry.Channel != "" {
+			fmt.Fprintf(&b, " in <#%s>", entry.Channel)
+		}
+		ms := strconv.FormatInt(entry.Time.UnixMilli(), 10)
+		n := strconv.Itoa(nth[entry])
+		components = append(components,
+			discordgo.Separator{},
+			discordgo.TextDisplay{Content: b.String()},
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Roll again",
+						Style:    discordgo.PrimaryButton,
+						CustomID: newComponentID("history", "roll", ms, n),
+					},
+					discordgo.Button{
+						Label:    "Save",
+						Style:    discordgo.SecondaryButton,
+						CustomID: newComponentID("history", "save", ms, n),
+					},
+				},
+			},
+		)
+	}
+
+	components = []discordgo.MessageComponent{
+		discordgo.Container{Components: components},
+	}
+	if pages > 1 {
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Previous",
+					Style:    discordgo.SecondaryButton,
+					CustomID: newComponentID("history", "page", strconv.Itoa(page-1), filter),
+					Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label:    fmt.Sprintf("%d/%d", page+1, pages),
+					Style:    discordgo.SecondaryButton,
+					CustomID: newComponentID("history", "page", strconv.Itoa(page), filter),
+					Disabled: true,
+				},
+				discordgo.Button{
+					Label:    "Next",
+					Style:    discordgo.SecondaryButton,
+					CustomID: newComponentID("history", "page", strconv.Itoa(page+1), filter),
+					Disabled: page == pages-1,
+				},
+			},
+		})
+	}
+	return components
+}
+
+// InteractionHistory sends the user's paginated roll history.
+func InteractionHistory(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "history"}, 1)
+
+	var filter string
+	if opt := getOptionByName(i.ApplicationCommandData().Options, "filter"); opt != nil {
+		// keep the filter short enough to fit within page button IDs
+		filter = truncString(strings.TrimSpace(opt.StringValue()), 50)
+	}
+
+	entries, err := GetHistory(ctx, UserFromInteraction(i))
+	if err != nil {
+		logger.Error("error getting history", zap.Error(err))
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:      discordgo.MessageFlagsEphemeral | discordgo.MessageFlagsIsComponentsV2,
+			Components: makeHistoryComponents(entries, 0, filter),
+		},
+	}); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
+
+// InteractionHistoryComponent handles the page, re-roll and save buttons of a
+// roll history message.
+func InteractionHistoryComponent(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	u := UserFromInteraction(i)
+	_, args := parseComponentID(i.MessageComponentData().CustomID)
+	if len(args) < 2 {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+		return
+	}
+
+	switch args[0] {
+	case "page":
+		page, _ := strconv.Atoi(args[1])
+		// filters may contain the ID delimiter
+		filter := strings.Join(args[2:], ":")
+		entries, err := GetHistory(ctx, u)
+		if err != nil {
+			logger.Error("error getting history", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Flags:      discordgo.MessageFlagsIsComponentsV2,
+				Components: makeHistoryComponents(entries, page, filter),
+			},
+		}); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+	case "roll", "save":
+		var n int
+		if len(args) > 2 {
+			n, _ = strconv.Atoi(args[2])
+		}
+		entry, err := GetHistoryEntry(ctx, u, args[1], n)
+		if err != nil {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry! That roll is no longer in your history."))
+			return
+		}
+		if args[0] == "save" {
+			if err := MeasureInteractionRespond(s.InteractionRespond, i, makeSaveExpressionModal(entry.NamedRollInput())); err != nil {
+				logger.Error("modal send", zap.Error(err))
+			}
+			return
+		}
+		RollMacroInteraction(ctx, entry.NamedRollInput().RollableString())
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+	}
+}
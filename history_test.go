@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestHistoryEntry_Matches(t *testing.T) {
+	tests := []struct {
+		name   string
+		entry  *HistoryEntry
+		filter string
+		want   bool
+	}{
+		{name: "empty", entry: &HistoryEntry{Expression: "1d20"}, filter: "", want: true},
+		{name: "expression", entry: &HistoryEntry{Expression: "1d20+3"}, filter: "d20", want: true},
+		{name: "label", entry: &HistoryEntry{Expression: "2d6", Label: "Fire Bolt"}, filter: "fire", want: true},
+		{name: "miss", entry: &HistoryEntry{Expression: "2d6", Label: "damage"}, filter: "d20", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.Matches(tt.filter); got != tt.want {
+				t.Errorf("HistoryEntry.Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/armon/go-metrics"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// maxConditions is the most conditions a combatant can have at once.
+const maxConditions = 10
+
+// A Condition is a condition affecting a combatant. Conditions with rounds
+// left count down at the start of the combatant's turn, and conditions without
+// last until they're removed.
+type Condition struct {
+	Name   string `json:"name"`
+	Rounds int    `json:"rounds,omitempty"`
+}
+
+// A CombatantStatus is the hit points and conditions of a combatant.
+type CombatantStatus struct {
+	HP         int          `json:"hp"`
+	MaxHP      int          `json:"max_hp,omitempty"`
+	TempHP     int          `json:"temp_hp,omitempty"`
+	Conditions []*Condition `json:"conditions,omitempty"`
+}
+
+// Damage applies damage to the combatant, reducing temporary hit points first.
+// Hit points don't drop below 0.
+func (cs *CombatantStatus) Damage(amount int) {
+	absorbed := min(cs.TempHP, amount)
+	cs.TempHP -= absorbed
+	cs.HP = max(cs.HP-(amount-absorbed), 0)
+}
+
+// Heal restores hit points to the combatant, up to their maximum if set.
+func (cs *CombatantStatus) Heal(amount int) {
+	cs.HP += amount
+	if cs.MaxHP > 0 {
+		cs.HP = min(cs.HP, cs.MaxHP)
+	}
+}
+
+// SetTemp gives the combatant temporary hit points. Temporary hit points don't
+// stack; the higher amount is kept.
+func (cs *CombatantStatus) SetTemp(amount int) {
+	cs.TempHP = max(cs.TempHP, amount)
+}
+
+// AddCondition adds a condition to the combatant, replacing the duration of a
+// condition they already have.
+func (cs *CombatantStatus) AddCondition(name string, rounds int) bool {
+	for _, c := range cs.Conditions {
+		if strings.EqualFold(c.Name, name) {
+			c.Rounds = rounds
+			return true
+		}
+	}
+	if len(cs.Conditions) >= maxConditions {
+		return false
+	}
+	cs.Conditions = append(cs.Conditions, &Condition{Name: name, Rounds: rounds})
+	return true
+}
+
+// RemoveCondition removes a condition from the combatant, returning whether
+// they had it.
+func (cs *CombatantStatus) RemoveCondition(name string) bool {
+	n := len(cs.Conditions)
+	cs.Conditions = slices.DeleteFunc(cs.Conditions, func(c *Condition) bool { return strings.EqualFold(c.Name, name) })
+	return len(cs.Conditions) != n
+}
+
+// Tick counts down the combatant's conditions by a round, returning the names
+// of conditions that expired.
+func (cs *CombatantStatus) Tick() (expired []string) {
+	cs.Conditions = slices.DeleteFunc(cs.Conditions, func(c *Condition) bool {
+		if c.Rounds == 0 {
+			return false
+		}
+		c.Rounds--
+		if c.Rounds == 0 {
+			expired = append(expired, c.Name)
+			return true
+		}
+		return false
+	})
+	return expired
+}
+
+// String renders the combatant's hit points and conditions, ex. "❤️ 12/20 +5
+// · Prone (2)".
+func (cs *CombatantStatus) String() string {
+	var parts []string
+	if cs.HP > 0 || cs.MaxHP > 0 || cs.TempHP > 0 {
+		hp := fmt.Sprintf("❤️ %d", cs.HP)
+		if cs.HP == 0 {
+			hp = "💀 0"
+		}
+		if cs.MaxHP > 0 {
+			hp += fmt.Sprintf("/%d", cs.MaxHP)
+		}
+		if cs.TempHP > 0 {
+			hp += fmt.Sprintf(" +%d", cs.TempHP)
+		}
+		parts = append(parts, hp)
+	}
+	for _, c := range cs.Conditions {
+		if c.Rounds > 0 {
+			parts = append(parts, fmt.Sprintf("_%s_ (%d)", c.Name, c.Rounds))
+		} else {
+			parts = append(parts, "_"+c.Name+"_")
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// getCombatantStatuses returns the statuses of a channel's combatants, by name.
+func getCombatantStatuses(ctx context.Context, cid string) (map[string]*CombatantStatus, error) {
+	data, err := DiceGolem.Cache.Redis.HGetAll(ctx, fmt.Sprintf(KeyChannelInitiativeStatusFmt, cid)).Result()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make(map[string]*CombatantStatus, len(data))
+	for name, v := range data {
+		status := new(CombatantStatus)
+		if err := json.Unmarshal([]byte(v), status); err == nil {
+			statuses[name] = status
+		}
+	}
+	return statuses, nil
+}
+
+// SetCombatantStatus saves the status of a combatant in a channel.
+func SetCombatantStatus(ctx context.Context, cid, name string, status *CombatantStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf(KeyChannelInitiativeStatusFmt, cid)
+	_, err = DiceGolem.Cache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, name, string(data))
+		pipe.Expire(ctx, key, DiceGolem.DataTTL)
+		return nil
+	})
+	return err
+}
+
+// InteractionHp tracks the hit points and conditions of combatants in a
+// channel's initiative order.
+func InteractionHp(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "hp"}, 1)
+
+	if DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	subcommand := options[0].Name
+	options = options[0].Options
+
+	tracker, err := GetInitiative(ctx, i.ChannelID)
+	if err != nil {
+		logger.Error("error getting initiative", zap.Error(err))
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+	target := strings.TrimSpace(mustGetOptionByName(options, "target").StringValue())
+	j := slices.IndexFunc(tracker.Combatants, func(c *Combatant) bool { return strings.EqualFold(c.Name, target) })
+	if j < 0 {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+			fmt.Sprintf("`%s` isn't in this channel's initiative order. Use %s to add them.", target, CommandMention("init", "join"))))
+		return
+	}
+	c := tracker.Combatants[j]
+	if c.Status == nil {
+		c.Status = new(CombatantStatus)
+	}
+
+	var note string
+	switch subcommand {
+	case "damage", "heal", "temp":
+		expression := mustGetOptionByName(options, "amount").StringValue()
+		res, err := evaluateCombatRoll(ctx, expression, subcommand)
+		if err != nil {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(createFriendlyError(err).Error()))
+			return
+		}
+		amount := max(int(res.ExpressionResult.Result), 0)
+		switch subcommand {
+		case "damage":
+			c.Status.Damage(amount)
+			note = fmt.Sprintf("%s took %d damage", c.Name, amount)
+		case "heal":
+			c.Status.Heal(amount)
+			note = fmt.Sprintf("%s healed %d hit points", c.Name, amount)
+		case "temp":
+			c.Status.SetTemp(amount)
+			note = fmt.Sprintf("%s gained %d temporary hit points", c.Name, amount)
+		}
+		note += fmt.Sprintf(" (`%s`: `%s` = %s)", expression, res.Rolled, res.Result)
+	case "set":
+		c.Status.HP = int(mustGetOptionByName(options, "hp").IntValue())
+		if opt := getOptionByName(options, "max"); opt != nil {
+			c.Status.MaxHP = int(opt.IntValue())
+		}
+		note = fmt.Sprintf("%s's hit points were set to %d", c.Name, c.Status.HP)
+	case "condition":
+		condition := strings.TrimSpace(mustGetOptionByName(options, "condition").StringValue())
+		if opt := getOptionByName(options, "remove"); opt != nil && opt.BoolValue() {
+			if !c.Status.RemoveCondition(condition) {
+				MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+					fmt.Sprintf("%s isn't affected by _%s_.", c.Name, condition)))
+				return
+			}
+			note = fmt.Sprintf("%s is no longer _%s_", c.Name, condition)
+			break
+		}
+		var rounds int
+		if opt := getOptionByName(options, "rounds"); opt != nil {
+			rounds = int(opt.IntValue())
+		}
+		if !c.Status.AddCondition(condition, rounds) {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+				fmt.Sprintf("Combatants can only have up to %d conditions.", maxConditions)))
+			return
+		}
+		note = fmt.Sprintf("%s is _%s_", c.Name, condition)
+		if rounds > 0 {
+			note += fmt.Sprintf(" for %s", pluralize(rounds, "round", "rounds"))
+		}
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry, an invalid subcommand was received!"))
+		return
+	}
+
+	if err := SetCombatantStatus(ctx, i.ChannelID, c.Name, c.Status); err != nil {
+		logger.Error("error saving combatant status", zap.Error(err))
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+	respondInitiative(ctx, tracker, note+".", false)
+}
+
+// SuggestCombatants suggests the names of combatants in the channel's
+// initiative order.
+func SuggestCombatants(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	var names []string
+	if DiceGolem.Cache.Redis != nil {
+		if tracker, err := GetInitiative(ctx, i.ChannelID); err == nil {
+			names = tracker.Order()
+		}
+	}
+	input := getOptionByName(i.ApplicationCommandData().Options, "target").StringValue()
+	choices := ChoicesFromStrings(names)
+	if input != "" {
+		choices = fuzzyFilterOptionChoices(input, choices)
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, newChoicesResponse(trunc(choices, 25))); err != nil {
+		logger.Error("autocomplete", zap.Error(err))
+	}
+}
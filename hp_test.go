@@ -0,0 +1,86 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCombatantStatus_Damage(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     CombatantStatus
+		amount     int
+		wantHP     int
+		wantTempHP int
+	}{
+		{name: "damage", status: CombatantStatus{HP: 20}, amount: 7, wantHP: 13},
+		{name: "temp absorbs", status: CombatantStatus{HP: 20, TempHP: 5}, amount: 3, wantHP: 20, wantTempHP: 2},
+		{name: "temp overflow", status: CombatantStatus{HP: 20, TempHP: 5}, amount: 8, wantHP: 17},
+		{name: "down", status: CombatantStatus{HP: 4}, amount: 10, wantHP: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.status.Damage(tt.amount)
+			if tt.status.HP != tt.wantHP || tt.status.TempHP != tt.wantTempHP {
+				t.Errorf("Damage() = %d (+%d), want %d (+%d)", tt.status.HP, tt.status.TempHP, tt.wantHP, tt.wantTempHP)
+			}
+		})
+	}
+}
+
+func TestCombatantStatus_Heal(t *testing.T) {
+	capped := CombatantStatus{HP: 15, MaxHP: 20}
+	capped.Heal(10)
+	if capped.HP != 20 {
+		t.Errorf("Heal() = %d, want %d", capped.HP, 20)
+	}
+	uncapped := CombatantStatus{HP: 15}
+	uncapped.Heal(10)
+	if uncapped.HP != 25 {
+		t.Errorf("Heal() = %d, want %d", uncapped.HP, 25)
+	}
+	temp := CombatantStatus{TempHP: 5}
+	temp.SetTemp(3)
+	if temp.TempHP != 5 {
+		t.Errorf("SetTemp() = %d, want %d", temp.TempHP, 5)
+	}
+}
+
+func TestCombatantStatus_Tick(t *testing.T) {
+	status := &CombatantStatus{}
+	status.AddCondition("Prone", 0)
+	status.AddCondition("Blessed", 1)
+	status.AddCondition("Stunned", 2)
+	if expired := status.Tick(); !reflect.DeepEqual(expired, []string{"Blessed"}) {
+		t.Errorf("Tick() = %v, want %v", expired, []string{"Blessed"})
+	}
+	if got, want := status.String(), "_Prone_ · _Stunned_ (1)"; got != want {
+		t.Errorf("String() = %v, want %v", got, want)
+	}
+	if expired := status.Tick(); !reflect.DeepEqual(expired, []string{"Stunned"}) {
+		t.Errorf("Tick() = %v, want %v", expired, []string{"Stunned"})
+	}
+	if !status.RemoveCondition("prone") || len(status.Conditions) != 0 {
+		t.Errorf("RemoveCondition() left %v", status.Conditions)
+	}
+}
+
+func TestCombatantStatus_String(t *testing.T) {
+	tests := []struct {
+		name   string
+		status CombatantStatus
+		want   string
+	}{
+		{name: "empty", status: CombatantStatus{}, want: ""},
+		{name: "hp", status: CombatantStatus{HP: 12, MaxHP: 20, TempHP: 5}, want: "❤️ 12/20 +5"},
+		{name: "down", status: CombatantStatus{HP: 0, MaxHP: 20}, want: "💀 0/20"},
+		{name: "conditions", status: CombatantStatus{HP: 7, Conditions: []*Condition{{Name: "Prone", Rounds: 2}}}, want: "❤️ 7 · _Prone_ (2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.status.String(); got != tt.want {
+				t.Errorf("String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
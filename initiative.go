@@ -0,0 +1,523 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/redis/go-redis/v9"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// maxCombatants is the most combatants an initiative order can have, limited by
+// the number of components Discord allows in a message.
+const maxCombatants = 10
+
+// initiativeModifierScale separates initiative totals from the modifiers used
+// to break ties when they're combined into a single score.
+const initiativeModifierScale = 1000
+
+// A Combatant is an entry in a channel's initiative order.
+type Combatant struct {
+	Name     string
+	Total    int
+	Modifier int
+	// Status of the combatant, if their hit points or conditions are tracked
+	Status *CombatantStatus
+}
+
+// initiativeScore combines an initiative total and its modifier into a score
+// that sorts by total, then by modifier.
+func initiativeScore(total, modifier int) float64 {
+	modifier = min(max(modifier, -initiativeModifierScale/2+1), initiativeModifierScale/2-1)
+	return float64(total*initiativeModifierScale + modifier)
+}
+
+// splitInitiativeScore splits a score back into its initiative total and
+// modifier.
+func splitInitiativeScore(score float64) (total, modifier int) {
+	total = int(math.Round(score / initiativeModifierScale))
+	return total, int(score) - total*initiativeModifierScale
+}
+
+// initiativeModifier returns the modifier of an initiative roll: the part of
+// its result that didn't come from the dice kept.
+func initiativeModifier(res *Response) int {
+	ctx := context.Background()
+	var rolled float64
+	eachDie(res.Groups(), func(d *dice.Die) {
+		if !d.IsDropped(ctx) {
+			rolled += d.Result.Value
+		}
+	})
+	return int(res.ExpressionResult.Result - rolled)
+}
+
+// advanceTurn moves the turn by step through an initiative order, returning
+// the combatant whose turn it is and the round. Wrapping around the order
+// changes the round. If the current combatant isn't in the order, the first
+// combatant takes their turn.
+func advanceTurn(order []string, current string, round, step int) (string, int) {
+	if len(order) == 0 {
+		return "", round
+	}
+	i := slices.Index(order, current)
+	if i < 0 {
+		return order[0], max(round, 1)
+	}
+	i += step
+	switch {
+	case i >= len(order):
+		return order[0], round + 1
+	case i < 0:
+		if round <= 1 {
+			return order[0], 1
+		}
+		return order[len(order)-1], round - 1
+	default:
+		return order[i], round
+	}
+}
+
+// An Initiative is a channel's initiative order and whose turn it is.
+type Initiative struct {
+	Combatants []*Combatant
+	Turn       string
+	Round      int
+	// ID of the message showing the tracker, if any
+	Message string
+}
+
+// Order returns the names of the combatants in initiative order.
+func (t *Initiative) Order() []string {
+	order := make([]string, len(t.Combatants))
+	for i, c := range t.Combatants {
+		order[i] = c.Name
+	}
+	return order
+}
+
+// GetInitiative returns the initiative order of a channel.
+func GetInitiative(ctx context.Context, cid string) (*Initiative, error) {
+	zs, err := DiceGolem.Cache.Redis.ZRevRangeWithScores(ctx, fmt.Sprintf(KeyChannelInitiativeFmt, cid), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	state, err := DiceGolem.Cache.Redis.HGetAll(ctx, fmt.Sprintf(KeyChannelInitiativeStateFmt, cid)).Result()
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := getCombatantStatuses(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+	tracker := &Initiative{Turn: state["turn"], Message: state["message"]}
+	tracker.Round, _ = strconv.Atoi(state["round"])
+	for _, z := range zs {
+		total, modifier := splitInitiativeScore(z.Score)
+		name := z.Member.(string)
+		tracker.Combatants = append(tracker.Combatants, &Combatant{
+			Name:     name,
+			Total:    total,
+			Modifier: modifier,
+			Status:   statuses[name],
+		})
+	}
+	return tracker, nil
+}
+
+// AddCombatant adds a combatant to a channel's initiative order, replacing any
+// combatant with the same name.
+func AddCombatant(ctx context.Context, cid string, c *Combatant) error {
+	key := fmt.Sprintf(KeyChannelInitiativeFmt, cid)
+	_, err := DiceGolem.Cache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, redis.Z{Score: initiativeScore(c.Total, c.Modifier), Member: c.Name})
+		pipe.Expire(ctx, key, DiceGolem.DataTTL)
+		return nil
+	})
+	return err
+}
+
+// SetTurn sets whose turn it is in a channel's initiative order.
+func SetTurn(ctx context.Context, cid, turn string, round int) error {
+	key := fmt.Sprintf(KeyChannelInitiativeStateFmt, cid)
+	_, err := DiceGolem.Cache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "turn", turn, "round", round)
+		pipe.Expire(ctx, key, DiceGolem.DataTTL)
+		return nil
+	})
+	return err
+}
+
+// SetInitiativeMessage sets the message showing a channel's initiative
+// tracker, which is edited as the initiative order changes.
+func SetInitiativeMessage(ctx context.Context, cid, mid string) error {
+	key := fmt.Sprintf(KeyChannelInitiativeStateFmt, cid)
+	_, err := DiceGolem.Cache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "message", mid)
+		pipe.Expire(ctx, key, DiceGolem.DataTTL)
+		return nil
+	})
+	return err
+}
+
+// RemoveCombatant removes a combatant from a channel's initiative order. If it
+// was their turn, the turn passes to the next combatant.
+func RemoveCombatant(ctx context.Context, cid string, tracker *Initiative, name string) error {
+	if tracker.Turn == name {
+		turn, round := advanceTurn(tracker.Order(), tracker.Turn, tracker.Round, 1)
+		if turn == name {
+			turn = ""
+		}
+		if err := SetTurn(ctx, cid, turn, round); err != nil {
+			return err
+		}
+		tracker.Turn, tracker.Round = turn, round
+	}
+	tracker.Combatants = slices.DeleteFunc(tracker.Combatants, func(c *Combatant) bool { return c.Name == name })
+	_, err := DiceGolem.Cache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, fmt.Sprintf(KeyChannelInitiativeFmt, cid), name)
+		pipe.HDel(ctx, fmt.Sprintf(KeyChannelInitiativeStatusFmt, cid), name)
+		return nil
+	})
+	return err
+}
+
+// advanceInitiative moves the turn of a channel's initiative order by step,
+// returning a note of whose turn it is. Moving forward counts down the
+// conditions of the combatant whose turn it becomes.
+func advanceInitiative(ctx context.Context, cid string, tracker *Initiative, step int) (string, error) {
+	tracker.Turn, tracker.Round = advanceTurn(tracker.Order(), tracker.Turn, tracker.Round, step)
+	if err := SetTurn(ctx, cid, tracker.Turn, tracker.Round); err != nil {
+		return "", err
+	}
+	if tracker.Turn == "" {
+		return "", nil
+	}
+	note := fmt.Sprintf("It's %s's turn!", tracker.Turn)
+	i := slices.IndexFunc(tracker.Combatants, func(c *Combatant) bool { return c.Name == tracker.Turn })
+	if step < 1 || i < 0 || tracker.Combatants[i].Status == nil {
+		return note, nil
+	}
+	c := tracker.Combatants[i]
+	if expired := c.Status.Tick(); len(expired) > 0 {
+		note += fmt.Sprintf(" %s is no longer _%s_.", c.Name, strings.Join(expired, "_, _"))
+	}
+	return note, SetCombatantStatus(ctx, cid, c.Name, c.Status)
+}
+
+// ClearInitiative ends a channel's initiative order.
+func ClearInitiative(ctx context.Context, cid string) error {
+	return DiceGolem.Cache.Redis.Del(ctx,
+		fmt.Sprintf(KeyChannelInitiativeFmt, cid),
+		fmt.Sprintf(KeyChannelInitiativeStateFmt, cid),
+		fmt.Sprintf(KeyChannelInitiativeStatusFmt, cid),
+	).Err()
+}
+
+// makeInitiativeComponents renders an initiative tracker as Components V2
+// message components, with a note like the latest roll shown beneath it.
+func makeInitiativeComponents(tracker *Initiative, note string) []discordgo.MessageComponent {
+	title := "## Initiative"
+	if tracker.Round > 0 {
+		title += fmt.Sprintf(" · Round %d", tracker.Round)
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.TextDisplay{Content: title},
+		discordgo.Separator{},
+	}
+	if len(tracker.Combatants) == 0 {
+		components = append(components, discordgo.TextDisplay{
+			Content: fmt.Sprintf("No one has joined yet. Roll initiative with %s!", CommandMention("init", "join")),
+		})
+	}
+	for _, c := range tracker.Combatants {
+		line := fmt.Sprintf("`%3d` %s", c.Total, c.Name)
+		if c.Name == tracker.Turn {
+			line = fmt.Sprintf("▶️ `%3d` **%s**", c.Total, c.Name)
+		}
+		if c.Modifier != 0 {
+			line += fmt.Sprintf(" (%+d)", c.Modifier)
+		}
+		if c.Status != nil {
+			if status := c.Status.String(); status != "" {
+				line += "\n" + status
+			}
+		}
+		components = append(components, discordgo.Section{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextDisplay{Content: line},
+			},
+			Accessory: discordgo.Button{
+				Label:    "Remove",
+				Style:    discordgo.SecondaryButton,
+				CustomID: newComponentID("init", "remove", c.Name),
+			},
+		})
+	}
+	if note != "" {
+		components = append(components, discordgo.TextDisplay{Content: "-# " + note})
+	}
+	return []discordgo.MessageComponent{
+		discordgo.Container{Components: components},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Previous",
+					Style:    discordgo.SecondaryButton,
+					CustomID: newComponentID("init", "previous"),
+					Disabled: tracker.Turn == "",
+				},
+				discordgo.Button{
+					Label:    "Next",
+					Style:    discordgo.PrimaryButton,
+					CustomID: newComponentID("init", "next"),
+					Disabled: len(tracker.Combatants) == 0,
+				},
+			},
+		},
+	}
+}
+
+// evaluateCombatRoll rolls an expression for combat, like initiative or
+// damage, without interpreting its result.
+func evaluateCombatRoll(ctx context.Context, expression, label string) (*Response, error) {
+	res, err := EvaluateRollInputWithContext(WithResultInterpreter(ctx, nil), &NamedRollInput{
+		Expression: expression,
+		Label:      label,
+	})
+	if err == nil && (res == nil || res.ExpressionResult == nil) {
+		err = ErrNilExpressionResult
+	}
+	return res, err
+}
+
+// newInitiativeResponse creates a response posting an initiative tracker.
+func newInitiativeResponse(tracker *Initiative, note string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:      discordgo.MessageFlagsIsComponentsV2,
+			Components: makeInitiativeComponents(tracker, note),
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Users: []string{},
+			},
+		},
+	}
+}
+
+// respondInitiative responds to an initiative or hit point command by editing
+// the channel's tracker message in place and replying with the note
+// ephemerally. If the tracker can't be edited, or repost is set, a new tracker
+// is posted and the old one is deleted.
+func respondInitiative(ctx context.Context, tracker *Initiative, note string, repost bool) {
+	s, i, _ := FromContext(ctx)
+	if tracker.Message != "" && !repost {
+		components := makeInitiativeComponents(tracker, note)
+		_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         tracker.Message,
+			Channel:    i.ChannelID,
+			Flags:      discordgo.MessageFlagsIsComponentsV2,
+			Components: &components,
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Users: []string{},
+			},
+		})
+		if err == nil {
+			gid := i.GuildID
+			if gid == "" {
+				gid = "@me"
+			}
+			content := fmt.Sprintf("Updated the [initiative tracker](https://discord.com/channels/%s/%s/%s).", gid, i.ChannelID, tracker.Message)
+			if note != "" {
+				content = note + "\n-# " + content
+			}
+			if err := MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(content)); err != nil {
+				logger.Error("error sending response", zap.Error(err))
+			}
+			return
+		}
+		logger.Debug("error editing initiative tracker", zap.String("message", tracker.Message), zap.Error(err))
+	}
+
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, newInitiativeResponse(tracker, note)); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+		return
+	}
+	m, err := s.InteractionResponse(i)
+	if err != nil {
+		logger.Error("error getting initiative tracker", zap.Error(err))
+		return
+	}
+	if tracker.Message != "" && tracker.Message != m.ID {
+		// the old tracker may already be gone
+		_ = s.ChannelMessageDelete(i.ChannelID, tracker.Message)
+	}
+	if err := SetInitiativeMessage(ctx, i.ChannelID, m.ID); err != nil {
+		logger.Error("error setting initiative tracker", zap.Error(err))
+	}
+}
+
+// InteractionInit manages a channel's initiative order.
+func InteractionInit(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "init"}, 1)
+
+	if DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	subcommand := options[0].Name
+	options = options[0].Options
+
+	if subcommand == "clear" {
+		if err := ClearInitiative(ctx, i.ChannelID); err != nil {
+			logger.Error("error clearing initiative", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Initiative cleared. Combat is over!",
+			},
+		}); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+		return
+	}
+
+	tracker, err := GetInitiative(ctx, i.ChannelID)
+	if err != nil {
+		logger.Error("error getting initiative", zap.Error(err))
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	var note string
+	switch subcommand {
+	case "join":
+		expression := "1d20"
+		if opt := getOptionByName(options, "expression"); opt != nil {
+			expression = opt.StringValue()
+		}
+		name := UserFromInteraction(i).DisplayName()
+		if i.Member != nil && i.Member.Nick != "" {
+			name = i.Member.Nick
+		}
+		if opt := getOptionByName(options, "name"); opt != nil && strings.TrimSpace(opt.StringValue()) != "" {
+			name = strings.TrimSpace(opt.StringValue())
+		}
+		if !slices.Contains(tracker.Order(), name) && len(tracker.Combatants) >= maxCombatants {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+				fmt.Sprintf("Initiative orders can only have up to %d combatants.", maxCombatants)))
+			return
+		}
+
+		res, err := evaluateCombatRoll(ctx, expression, "Initiative")
+		if err != nil {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(createFriendlyError(err).Error()))
+			return
+		}
+		c := &Combatant{
+			Name:     name,
+			Total:    int(res.ExpressionResult.Result),
+			Modifier: initiativeModifier(res),
+		}
+		if err := AddCombatant(ctx, i.ChannelID, c); err != nil {
+			logger.Error("error joining initiative", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if j := slices.IndexFunc(tracker.Combatants, func(other *Combatant) bool { return other.Name == name }); j >= 0 {
+			c.Status = tracker.Combatants[j].Status
+			tracker.Combatants = slices.Delete(tracker.Combatants, j, j+1)
+		}
+		tracker.Combatants = append(tracker.Combatants, c)
+		slices.SortStableFunc(tracker.Combatants, func(a, b *Combatant) int {
+			if d := b.Total - a.Total; d != 0 {
+				return d
+			}
+			return b.Modifier - a.Modifier
+		})
+		note = fmt.Sprintf("%s rolled `%s`: `%s` = %s", name, expression, res.Rolled, res.Result)
+	case "next":
+		if len(tracker.Combatants) == 0 {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+				fmt.Sprintf("No one has rolled initiative in this channel. Use %s to join.", CommandMention("init", "join"))))
+			return
+		}
+		if note, err = advanceInitiative(ctx, i.ChannelID, tracker, 1); err != nil {
+			logger.Error("error setting turn", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+	case "list":
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry, an invalid subcommand was received!"))
+		return
+	}
+	respondInitiative(ctx, tracker, note, subcommand == "list")
+}
+
+// InteractionInitComponent handles the buttons of an initiative tracker,
+// updating the tracker in place.
+func InteractionInitComponent(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "init"}, 1)
+	_, args := parseComponentID(i.MessageComponentData().CustomID)
+	if len(args) == 0 || DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+		return
+	}
+
+	tracker, err := GetInitiative(ctx, i.ChannelID)
+	if err != nil {
+		logger.Error("error getting initiative", zap.Error(err))
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	var note string
+	switch args[0] {
+	case "next", "previous":
+		step := 1
+		if args[0] == "previous" {
+			step = -1
+		}
+		note, err = advanceInitiative(ctx, i.ChannelID, tracker, step)
+	case "remove":
+		// names may contain the ID delimiter
+		name := strings.Join(args[1:], ":")
+		err = RemoveCombatant(ctx, i.ChannelID, tracker, name)
+		note = fmt.Sprintf("%s was removed by %s.", name, UserFromInteraction(i).Mention())
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+		return
+	}
+	if err != nil {
+		logger.Error("error updating initiative", zap.Error(err))
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	// the tracker that was used becomes the one to keep updated
+	if i.Message != nil && i.Message.ID != tracker.Message {
+		if err := SetInitiativeMessage(ctx, i.ChannelID, i.Message.ID); err != nil {
+			logger.Error("error setting initiative tracker", zap.Error(err))
+		}
+	}
+	response := newInitiativeResponse(tracker, note)
+	response.Type = discordgo.InteractionResponseUpdateMessage
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, response); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
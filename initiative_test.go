@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/travis-g/dice"
+	"github.com/travis-g/dice/math"
+)
+
+func Test_initiativeScore(t *testing.T) {
+	tests := []struct {
+		name            string
+		total, modifier int
+	}{
+		{name: "positive", total: 17, modifier: 3},
+		{name: "negative modifier", total: 8, modifier: -2},
+		{name: "negative total", total: -3, modifier: -4},
+		{name: "zero", total: 0, modifier: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, modifier := splitInitiativeScore(initiativeScore(tt.total, tt.modifier))
+			if total != tt.total || modifier != tt.modifier {
+				t.Errorf("splitInitiativeScore() = %d, %d, want %d, %d", total, modifier, tt.total, tt.modifier)
+			}
+		})
+	}
+	if initiativeScore(15, 5) <= initiativeScore(15, 2) {
+		t.Errorf("initiativeScore() should break ties by modifier")
+	}
+	if initiativeScore(16, -5) <= initiativeScore(15, 5) {
+		t.Errorf("initiativeScore() should sort by total first")
+	}
+}
+
+func Test_initiativeModifier(t *testing.T) {
+	res := &Response{
+		ExpressionResult: &math.ExpressionResult{
+			Result: 17,
+			Dice:   []*dice.RollerGroup{testRollerGroup(20, 14)},
+		},
+	}
+	if got := initiativeModifier(res); got != 3 {
+		t.Errorf("initiativeModifier() = %v, want %v", got, 3)
+	}
+}
+
+func Test_advanceTurn(t *testing.T) {
+	order := []string{"Aria", "Goblin", "Bram"}
+	tests := []struct {
+		name      string
+		order     []string
+		current   string
+		round     int
+		step      int
+		wantTurn  string
+		wantRound int
+	}{
+		{name: "start", order: order, current: "", round: 0, step: 1, wantTurn: "Aria", wantRound: 1},
+		{name: "next", order: order, current: "Aria", round: 1, step: 1, wantTurn: "Goblin", wantRound: 1},
+		{name: "wrap", order: order, current: "Bram", round: 1, step: 1, wantTurn: "Aria", wantRound: 2},
+		{name: "previous", order: order, current: "Goblin", round: 2, step: -1, wantTurn: "Aria", wantRound: 2},
+		{name: "previous round", order: order, current: "Aria", round: 2, step: -1, wantTurn: "Bram", wantRound: 1},
+		{name: "before start", order: order, current: "Aria", round: 1, step: -1, wantTurn: "Aria", wantRound: 1},
+		{name: "removed", order: order, current: "Orc", round: 3, step: 1, wantTurn: "Aria", wantRound: 3},
+		{name: "empty", order: nil, current: "", round: 2, step: 1, wantTurn: "", wantRound: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			turn, round := advanceTurn(tt.order, tt.current, tt.round, tt.step)
+			if turn != tt.wantTurn || round != tt.wantRound {
+				t.Errorf("advanceTurn() = %v, %v, want %v, %v", turn, round, tt.wantTurn, tt.wantRound)
+			}
+		})
+	}
+}
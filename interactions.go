@@ -69,6 +69,33 @@ var (
 		"buttons": InteractionButtons,
 		"ping":    InteractionPing,
 		"clear":   InteractionClear,
+		"data":    InteractionData,
+		"history": InteractionHistory,
+		"mystats": InteractionUserStats,
+		"session": InteractionSession,
+		"log":     InteractionLog,
+		"verify":  InteractionVerify,
+
+		// system commands
+		"check":     InteractionCheck,
+		"save":      InteractionCheck,
+		"attack":    InteractionAttack,
+		"pbta":      InteractionPbta,
+		"coc":       InteractionCoc,
+		"fitd":      InteractionFitd,
+		"fate":      InteractionFate,
+		"pool":      InteractionPool,
+		"dice":      InteractionDice,
+		"table":     InteractionTable,
+		"deck":      InteractionDeck,
+		"init":      InteractionInit,
+		"hp":        InteractionHp,
+		"contest":   InteractionContest,
+		"grouproll": InteractionGroupRoll,
+		"schedule":  InteractionSchedule,
+		"clock":     InteractionClock,
+
+		"configure": InteractionConfigure,
 
 		"preferences": InteractionPreferences,
 		"settings":    InteractionSettings,
@@ -84,6 +111,19 @@ var (
 		"Save Expression": SaveRollInteractionCreate,
 	}
 
+	// A map of handlers for message components with dynamic custom IDs, keyed
+	// by the handler name prefixing the custom ID (see newComponentID).
+	componentHandlers = map[string]func(ctx context.Context){
+		"data":      InteractionDataComponent,
+		"history":   InteractionHistoryComponent,
+		"verify":    InteractionVerifyComponent,
+		"fitd":      InteractionFitdComponent,
+		"init":      InteractionInitComponent,
+		"contest":   InteractionContestComponent,
+		"grouproll": InteractionGroupRollComponent,
+		"clock":     InteractionClockComponent,
+	}
+
 	suggesters = map[string]func(ctx context.Context){
 		"roll:expression":               SuggestRolls,
 		"secret:expression":             SuggestRolls,
@@ -96,6 +136,30 @@ var (
 		"expressions save:label":        SuggestLabel,
 		"expressions save:name":         SuggestNames,
 		"expressions unsave:expression": SuggestNames,
+		"check:label":                   SuggestLabel,
+		"save:label":                    SuggestLabel,
+		"attack:label":                  SuggestLabel,
+		"attack:damage":                 SuggestRolls,
+		"coc:label":                     SuggestLabel,
+		"fitd:label":                    SuggestLabel,
+		"fate:label":                    SuggestLabel,
+		"pool:label":                    SuggestLabel,
+		"init join:expression":          SuggestRolls,
+		"hp damage:target":              SuggestCombatants,
+		"hp heal:target":                SuggestCombatants,
+		"hp temp:target":                SuggestCombatants,
+		"hp set:target":                 SuggestCombatants,
+		"hp condition:target":           SuggestCombatants,
+		"contest:expression":            SuggestRolls,
+		"schedule roll:expression":      SuggestRolls,
+		"schedule cancel:roll":          SuggestScheduledRolls,
+		"clock tick:name":               SuggestClocks,
+		"clock roll:name":               SuggestClocks,
+		"clock show:name":               SuggestClocks,
+		"clock delete:name":             SuggestClocks,
+		"dice delete:name":              SuggestCustomDice,
+		"table roll:name":               SuggestTables,
+		"table delete:name":             SuggestTables,
 	}
 )
 
@@ -176,7 +240,7 @@ func RollInteractionCreateEphemeral(ctx context.Context) {
 	defer metrics.IncrCounter([]string{"roll", "ephemeral"}, 1)
 
 	// Tweak the InteractionResponse to be ephemeral
-	response.Data.Flags = discordgo.MessageFlagsEphemeral
+	response.Data.Flags |= discordgo.MessageFlagsEphemeral
 	if err := MeasureInteractionRespond(s.InteractionRespond, i, response); err != nil {
 		logger.Error("error sending response", zap.Error(err))
 		return
@@ -192,6 +256,8 @@ func RollInteractionCreatePrivate(ctx context.Context) {
 
 	uid := UserFromInteraction(i).ID
 
+	// DMs are sent as converted messages, which can't hold result cards
+	ctx = WithLegacyResponse(ctx)
 	rollData, response, rollErr := NewRollInteractionResponseFromInteraction(ctx)
 	if response == nil {
 		return
@@ -291,24 +357,7 @@ func NewRollInteractionResponseFromInteraction(ctx context.Context) (*Response,
 		return nil, nil, nil
 	}
 
-	message, response, err := NewRollInteractionResponseFromStringWithContext(ctx, input.RollableString())
-	if err != nil {
-		return message, response, err
-	}
-
-	detailed := UserHasPreference(UserFromInteraction(i), SettingDetailed)
-	optDetailed := getOptionByName(options, "detailed")
-	if optDetailed != nil {
-		detailed = optDetailed.BoolValue()
-	}
-
-	if detailed {
-		response.Data.Embeds = MessageEmbeds(ctx, &RollLog{
-			Entries: []*Response{message},
-		})
-	}
-
-	return message, response, err
+	return NewRollInteractionResponseFromStringWithContext(ctx, input.RollableString())
 }
 
 // NewRollInteractionResponseFromStringWithContext creates an Interaction
@@ -391,11 +440,28 @@ func NewRollInteractionResponseFromStringWithContext(ctx context.Context, expres
 	if optDetailed := getOptionByName(options, "detailed"); optDetailed != nil {
 		detailed = optDetailed.BoolValue()
 	}
+	var file *discordgo.File
+	if UserHasPreference(UserFromInteraction(i), SettingImage) {
+		if file = diceImageFile(ctx, message); file != nil {
+			response.Data.Files = append(response.Data.Files, file)
+		}
+	}
+	if useResultCards(ctx, UserFromInteraction(i), i.GuildID) {
+		response.Data.Content = ""
+		response.Data.Flags |= discordgo.MessageFlagsIsComponentsV2
+		response.Data.Components = []discordgo.MessageComponent{
+			makeResultCard(message, resultCardDetail(message, detailed), resultCardImage(file), rerollable(ctx)),
+		}
+		return message, response, nil
+	}
 	if detailed {
 		response.Data.Embeds = MessageEmbeds(ctx, &RollLog{
 			Entries: []*Response{message},
 		})
 	}
+	if message.Receipt != "" {
+		response.Data.Components = []discordgo.MessageComponent{makeVerifyButton(message.Receipt)}
+	}
 
 	return message, response, nil
 }
@@ -470,11 +536,35 @@ func NewRollMessageResponseFromString(ctx context.Context, content string) (*Res
 		},
 	}
 
-	if UserHasPreference(user, SettingDetailed) {
+	var file *discordgo.File
+	if UserHasPreference(user, SettingImage) {
+		if file = diceImageFile(ctx, res); file != nil {
+			message.Files = append(message.Files, file)
+		}
+	}
+	guildID := ""
+	if m != nil {
+		guildID = m.GuildID
+	} else if i != nil {
+		guildID = i.GuildID
+	}
+	detailed := UserHasPreference(user, SettingDetailed)
+	if useResultCards(ctx, user, guildID) {
+		message.Content = ""
+		message.Flags |= discordgo.MessageFlagsIsComponentsV2
+		message.Components = []discordgo.MessageComponent{
+			makeResultCard(res, resultCardDetail(res, detailed), resultCardImage(file), rerollable(ctx)),
+		}
+		return res, message, nil
+	}
+	if detailed {
 		message.Embeds = MessageEmbeds(ctx, &RollLog{
 			Entries: []*Response{res},
 		})
 	}
+	if res.Receipt != "" {
+		message.Components = []discordgo.MessageComponent{makeVerifyButton(res.Receipt)}
+	}
 
 	return res, message, nil
 }
@@ -705,6 +795,8 @@ func InteractionButtons(ctx context.Context) {
 		components = D20PadComponents
 	case "fate":
 		components = FatePadComponents
+	case "fitd":
+		components = FitdPadComponents
 	case "modifiers":
 		options := i.ApplicationCommandData().Options
 		base := mustGetOptionByName(options, "expression")
@@ -809,8 +901,10 @@ func InteractionClear(ctx context.Context) {
 	case "recent":
 		// clear out recent roll key from the cache
 		if DiceGolem.Cache.Redis != nil {
-			key := fmt.Sprintf(KeyCacheUserRecentFmt, u.ID)
-			DiceGolem.Cache.Redis.Del(ctx, key)
+			DiceGolem.Cache.Redis.Del(ctx,
+				fmt.Sprintf(KeyCacheUserRecentFmt, u.ID),
+				fmt.Sprintf(KeyCacheUserHistoryFmt, u.ID),
+			)
 		}
 		if err := MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Cleared your cached roll history (if any).")); err != nil {
 			logger.Error("error sending response", zap.Error(err))
@@ -839,15 +933,35 @@ func InteractionPreferences(ctx context.Context) {
 			UserUnsetPreference(user, SettingNoRecent)
 		} else {
 			UserSetPreference(user, SettingNoRecent)
-			DiceGolem.Cache.Redis.Del(ctx, fmt.Sprintf(KeyCacheUserRecentFmt, user.ID))
+			DiceGolem.Cache.Redis.Del(ctx,
+				fmt.Sprintf(KeyCacheUserRecentFmt, user.ID),
+				fmt.Sprintf(KeyCacheUserHistoryFmt, user.ID),
+			)
 		}
 	case "output":
-		option := mustGetOptionByName(options, "detailed")
-		if option.BoolValue() {
-			// set default of 'True'
-			UserSetPreference(user, SettingDetailed)
-		} else {
-			UserUnsetPreference(user, SettingDetailed)
+		if option := getOptionByName(options, "detailed"); option != nil {
+			if option.BoolValue() {
+				// set default of 'True'
+				UserSetPreference(user, SettingDetailed)
+			} else {
+				UserUnsetPreference(user, SettingDetailed)
+			}
+		}
+		if option := getOptionByName(options, "image"); option != nil {
+			if option.BoolValue() {
+				UserSetPreference(user, SettingImage)
+			} else {
+				UserUnsetPreference(user, SettingImage)
+			}
+		}
+		if option := getOptionByName(options, "cards"); option != nil {
+			if option.BoolValue() {
+				UserSetPreference(user, SettingCards)
+				UserUnsetPreference(user, SettingNoCards)
+			} else {
+				UserSetPreference(user, SettingNoCards)
+				UserUnsetPreference(user, SettingCards)
+			}
 		}
 	default:
 		panic(fmt.Sprintf("unhandled preference: %s", options[0].Name))
@@ -1006,8 +1120,8 @@ func UserFromMessage(m *discordgo.Message) (user *discordgo.User) {
 // other than the sender.
 func isInteractionPublic(i *discordgo.Interaction) bool {
 	fmt.Printf("%#v\n", i)
-	// if the command wasn't /roll or Roll Message, it's automatically private
-	if !contains([]string{"roll", "Roll Message"}, i.ApplicationCommandData().Name) {
+	// if the command doesn't share rolls, it's automatically private
+	if !contains(publicRollCommands, i.ApplicationCommandData().Name) {
 		return false
 	}
 
@@ -1027,6 +1141,26 @@ func isInteractionPublic(i *discordgo.Interaction) bool {
 	return true
 }
 
+// publicRollCommands are the commands that share their rolls with the channel
+// they were made in unless made secretly or privately.
+var publicRollCommands = []string{"roll", "Roll Message", "check", "save", "attack", "pbta", "coc", "fitd", "fate", "pool", "table", "deck"}
+
+// isRollPublic returns whether a roll in the context is being shared with the
+// channel it was made in. Message rolls and button presses are always public.
+func isRollPublic(ctx context.Context) bool {
+	_, i, m := FromContext(ctx)
+	switch {
+	case m != nil:
+		return true
+	case i != nil && i.Type == discordgo.InteractionMessageComponent:
+		return true
+	case i != nil && i.Type == discordgo.InteractionApplicationCommand:
+		return isInteractionPublic(i)
+	default:
+		return false
+	}
+}
+
 // ExpressionsClearInteraction drops a user's saved expressions from the backend
 // store, if they exit.
 func ExpressionsClearInteraction(ctx context.Context, u *discordgo.User) error {
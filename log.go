@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+)
+
+// Accepted layouts of dates for roll log exports.
+var sinceLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	time.DateOnly,
+}
+
+// parseSince parses a date or date-time string in UTC. An empty string yields
+// the zero time.
+func parseSince(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range sinceLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid date")
+}
+
+// ExportRollEvents encodes roll events as CSV and JSON file attachments.
+func ExportRollEvents(ctx context.Context, cid string, events []*RollEvent) ([]*discordgo.File, error) {
+	csv, err := gocsv.MarshalBytes(&events)
+	if err != nil {
+		return nil, err
+	}
+	js, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []*discordgo.File{
+		{
+			Name:        fmt.Sprintf("rolls-%s.csv", cid),
+			ContentType: "text/csv; charset=utf-8",
+			Reader:      bytes.NewReader(csv),
+		},
+		{
+			Name:        fmt.Sprintf("rolls-%s.json", cid),
+			ContentType: "application/json; charset=utf-8",
+			Reader:      bytes.NewReader(js),
+		},
+	}, nil
+}
+
+// InteractionLog handles channel roll log requests.
+func InteractionLog(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "log"}, 1)
+
+	if i.Member == nil || i.Member.Permissions&discordgo.PermissionManageGuild == 0 {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("This command requires the _Manage Server_ permission."))
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	switch options[0].Name {
+	case "export":
+		options = options[0].Options
+		cid := i.ChannelID
+		if opt := getOptionByName(options, "channel"); opt != nil {
+			cid = opt.ChannelValue(nil).ID
+		}
+		var since time.Time
+		if opt := getOptionByName(options, "since"); opt != nil {
+			var err error
+			if since, err = parseSince(opt.StringValue()); err != nil {
+				MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry! That date couldn't be read. Try a format like `2024-01-31` or `2024-01-31 18:30` (UTC)."))
+				return
+			}
+		}
+
+		events, err := GetRollEvents(ctx, cid, since)
+		if err != nil {
+			logger.Error("error getting roll events", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if len(events) == 0 {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(fmt.Sprintf("No rolls have been logged in <#%s> for that time.", cid)))
+			return
+		}
+		files, err := ExportRollEvents(ctx, cid, events)
+		if err != nil {
+			logger.Error("error exporting roll events", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags:   discordgo.MessageFlagsEphemeral,
+				Content: fmt.Sprintf("Exported %d rolls made in <#%s>. Be sure to download them!", len(events), cid),
+				Files:   files,
+			},
+		}); err != nil {
+			logger.Error("error sending export", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Something unexpected errored! The bot may be missing the _Attach Files_ permission."))
+		}
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry, an invalid subcommand was received!"))
+	}
+}
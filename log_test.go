@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_parseSince(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "empty", s: "", want: time.Time{}},
+		{name: "date", s: "2024-01-31", want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "datetime", s: "2024-01-31 18:30", want: time.Date(2024, 1, 31, 18, 30, 0, 0, time.UTC)},
+		{name: "rfc3339", s: "2024-01-31T18:30:00Z", want: time.Date(2024, 1, 31, 18, 30, 0, 0, time.UTC)},
+		{name: "invalid", s: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSince(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseSince() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseSince() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportRollEvents(t *testing.T) {
+	events := []*RollEvent{
+		{ID: "1", User: "2", Input: "1d20", Expression: "1d20", Rolled: "[20]", Dice: "[20]", Result: 20, Crits: 1, Time: time.UnixMilli(0).UTC()},
+	}
+	files, err := ExportRollEvents(context.Background(), "3", events)
+	if err != nil {
+		t.Fatalf("ExportRollEvents() error = %v", err)
+	}
+	if len(files) != 2 || files[0].Name != "rolls-3.csv" || files[1].Name != "rolls-3.json" {
+		t.Errorf("ExportRollEvents() files = %v", files)
+	}
+}
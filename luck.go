@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/redis/go-redis/v9"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// Streak directions of consecutive rolls above or below their expected value.
+const (
+	StreakHot  = "hot"
+	StreakCold = "cold"
+)
+
+// updateStreakScript extends a user's current hot or cold streak, resets the
+// opposite streak, and records the longest streak seen. An empty direction
+// resets both streaks.
+var updateStreakScript = redis.NewScript(`
+local dir = ARGV[1]
+for _, d in ipairs({"hot", "cold"}) do
+	if d ~= dir then
+		redis.call("HSET", KEYS[1], d, 0)
+	end
+end
+if dir == "" then
+	return 0
+end
+local n = redis.call("HINCRBY", KEYS[1], dir, 1)
+local best = tonumber(redis.call("HGET", KEYS[1], "best_" .. dir) or "0")
+if n > best then
+	redis.call("HSET", KEYS[1], "best_" .. dir, n)
+end
+return n
+`)
+
+// eachDie calls fn for every die rolled within a set of dice groups, including
+// dropped dice.
+func eachDie(groups []*dice.RollerGroup, fn func(*dice.Die)) {
+	var walk func(rollers []dice.Roller)
+	walk = func(rollers []dice.Roller) {
+		for _, roller := range rollers {
+			switch r := roller.(type) {
+			case *dice.Die:
+				if r.Result != nil {
+					fn(r)
+				}
+			case *dice.RollerGroup:
+				walk(r.Group)
+			case dice.Group:
+				walk(r)
+			}
+		}
+	}
+	for _, group := range groups {
+		if group != nil {
+			walk(group.Group)
+		}
+	}
+}
+
+// Fields of the count and sum of faces rolled on dice sizes without a face
+// histogram.
+const (
+	dieFieldCount = "count"
+	dieFieldSum   = "sum"
+)
+
+// isStandardDie returns whether a die size is common enough to track each of
+// its faces, which is d2 to d20 and d100.
+func isStandardDie(size int) bool {
+	return (size >= 2 && size <= 20) || size == 100
+}
+
+// dieSizeLabel returns the metrics label of a die size. Uncommon sizes share a
+// label so the number of labels stays bounded.
+func dieSizeLabel(size int) string {
+	if !isStandardDie(size) {
+		return "other"
+	}
+	return strconv.Itoa(size)
+}
+
+// countFaces tallies the faces rolled on polyhedral dice within a set of dice
+// groups. Faces of standard dice are keyed as "size:face", and other sizes
+// only keep a "size:count" and "size:sum" of their faces.
+func countFaces(groups []*dice.RollerGroup) map[string]int64 {
+	counts := make(map[string]int64)
+	eachDie(groups, func(d *dice.Die) {
+		if d.Type != dice.TypePolyhedron || d.Size < 2 {
+			return
+		}
+		if isStandardDie(d.Size) {
+			counts[fmt.Sprintf("%d:%d", d.Size, int(d.Result.Value))]++
+			return
+		}
+		counts[fmt.Sprintf("%d:%s", d.Size, dieFieldCount)]++
+		counts[fmt.Sprintf("%d:%s", d.Size, dieFieldSum)] += int64(d.Result.Value)
+	})
+	return counts
+}
+
+// streakDirection returns whether the polyhedral dice within a set of groups
+// rolled above or below their expected mean in total, or an empty string if
+// they were even or there were no dice.
+func streakDirection(groups []*dice.RollerGroup) string {
+	var delta float64
+	eachDie(groups, func(d *dice.Die) {
+		if d.Type != dice.TypePolyhedron || d.Size < 2 {
+			return
+		}
+		delta += d.Result.Value - float64(d.Size+1)/2
+	})
+	switch {
+	case delta > 0:
+		return StreakHot
+	case delta < 0:
+		return StreakCold
+	default:
+		return ""
+	}
+}
+
+// diceLuck returns the summed luck scores of each polyhedral die within a set
+// of groups (see DieStats.Luck) and the number of dice scored.
+func diceLuck(groups []*dice.RollerGroup) (luck float64, n int) {
+	eachDie(groups, func(d *dice.Die) {
+		if d.Type != dice.TypePolyhedron || d.Size < 2 {
+			return
+		}
+		luck += (d.Result.Value - float64(d.Size+1)/2) / float64(d.Size-1) * 200
+		n++
+	})
+	return
+}
+
+// trackDiceFromContext accumulates a user's rolled faces and streaks for luck
+// statistics and emits per-die metrics.
+func trackDiceFromContext(ctx context.Context, pipe redis.Pipeliner, uid string, groups []*dice.RollerGroup) {
+	faces := countFaces(groups)
+	for field, count := range faces {
+		pipe.HIncrBy(ctx, fmt.Sprintf(KeyUserRollsDiceFmt, uid), field, count)
+	}
+
+	// dice, min and max counts by size label
+	counts := make(map[string]*[3]float32)
+	eachDie(groups, func(d *dice.Die) {
+		if d.Type != dice.TypePolyhedron || d.Size < 2 {
+			return
+		}
+		label := dieSizeLabel(d.Size)
+		if counts[label] == nil {
+			counts[label] = new([3]float32)
+		}
+		counts[label][0]++
+		switch int(d.Result.Value) {
+		case 1:
+			counts[label][1]++
+		case d.Size:
+			counts[label][2]++
+		}
+	})
+	for label, c := range counts {
+		labels := []metrics.Label{{Name: "size", Value: label}}
+		metrics.IncrCounterWithLabels([]string{"rolls", "dice"}, c[0], labels)
+		if c[1] > 0 {
+			metrics.IncrCounterWithLabels([]string{"rolls", "dice", "min"}, c[1], labels)
+		}
+		if c[2] > 0 {
+			metrics.IncrCounterWithLabels([]string{"rolls", "dice", "max"}, c[2], labels)
+		}
+	}
+	if len(faces) > 0 {
+		updateStreakScript.Eval(ctx, pipe, []string{fmt.Sprintf(KeyUserRollsStreaksFmt, uid)}, streakDirection(groups))
+	}
+}
+
+// DieStats is the outcome distribution of a single die size for a user. Sizes
+// that aren't standard dice only have a count and sum of their faces.
+type DieStats struct {
+	Size  int
+	Faces map[int]int64
+	N     int64
+	Sum   int64
+}
+
+// Count returns the total number of dice rolled.
+func (d *DieStats) Count() (n int64) {
+	n = d.N
+	for _, c := range d.Faces {
+		n += c
+	}
+	return
+}
+
+// Mean returns the average face rolled.
+func (d *DieStats) Mean() float64 {
+	sum := float64(d.Sum)
+	for face, c := range d.Faces {
+		sum += float64(face) * float64(c)
+	}
+	return sum / float64(d.Count())
+}
+
+// Expected returns the expected average face of the die size.
+func (d *DieStats) Expected() float64 {
+	return float64(d.Size+1) / 2
+}
+
+// Luck returns a luck score from -100 (only ever rolled the lowest face) to 100
+// (only ever rolled the highest face), where 0 is exactly as expected.
+func (d *DieStats) Luck() float64 {
+	return (d.Mean() - d.Expected()) / float64(d.Size-1) * 200
+}
+
+// parseDieStats converts a user's "size:face" counts hash, with the
+// "size:count" and "size:sum" of other sizes, into distributions sorted by die
+// size.
+func parseDieStats(hash map[string]string) []*DieStats {
+	bySize := make(map[int]*DieStats)
+	for field, value := range hash {
+		sizeStr, faceStr, ok := strings.Cut(field, ":")
+		if !ok {
+			continue
+		}
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil || size < 2 {
+			continue
+		}
+		count, _ := strconv.ParseInt(value, 10, 64)
+		var face int
+		if faceStr != dieFieldCount && faceStr != dieFieldSum {
+			if face, err = strconv.Atoi(faceStr); err != nil {
+				continue
+			}
+		}
+		if _, ok := bySize[size]; !ok {
+			bySize[size] = &DieStats{Size: size, Faces: make(map[int]int64)}
+		}
+		switch faceStr {
+		case dieFieldCount:
+			bySize[size].N += count
+		case dieFieldSum:
+			bySize[size].Sum += count
+		default:
+			bySize[size].Faces[face] += count
+		}
+	}
+	stats := make([]*DieStats, 0, len(bySize))
+	for _, s := range bySize {
+		stats = append(stats, s)
+	}
+	slices.SortFunc(stats, func(a, b *DieStats) int {
+		return a.Size - b.Size
+	})
+	return stats
+}
+
+// overallLuck returns the luck score across all die sizes, weighted by the
+// number of dice rolled.
+func overallLuck(stats []*DieStats) float64 {
+	var luck float64
+	var total int64
+	for _, s := range stats {
+		luck += s.Luck() * float64(s.Count())
+		total += s.Count()
+	}
+	if total == 0 {
+		return 0
+	}
+	return luck / float64(total)
+}
+
+// markdownDistribution renders a die's face distribution as a text bar chart.
+func markdownDistribution(s *DieStats) string {
+	const width = 20
+	var most int64
+	for _, c := range s.Faces {
+		most = max(most, c)
+	}
+	var b strings.Builder
+	b.WriteString("```\n")
+	pad := len(strconv.Itoa(s.Size))
+	for face := 1; face <= s.Size; face++ {
+		c := s.Faces[face]
+		bar := 0
+		if most > 0 {
+			bar = int(math.Round(float64(c) / float64(most) * width))
+		}
+		fmt.Fprintf(&b, "%*d %s %d\n", pad, face, strings.Repeat("█", bar), c)
+	}
+	b.WriteString("```")
+	return b.String()
+}
+
+func makeEmbedUserStats(ctx context.Context, u *discordgo.User) (*discordgo.MessageEmbed, error) {
+	if DiceGolem.Cache.Redis == nil {
+		return nil, ErrNoRedisClient
+	}
+
+	var (
+		total   *redis.StringCmd
+		faces   *redis.MapStringStringCmd
+		streaks *redis.MapStringStringCmd
+	)
+	if _, err := DiceGolem.Cache.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		total = pipe.Get(ctx, fmt.Sprintf(KeyUserRollsTotalFmt, u.ID))
+		faces = pipe.HGetAll(ctx, fmt.Sprintf(KeyUserRollsDiceFmt, u.ID))
+		streaks = pipe.HGetAll(ctx, fmt.Sprintf(KeyUserRollsStreaksFmt, u.ID))
+		return nil
+	}); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	rolls, _ := total.Int64()
+	stats := parseDieStats(faces.Val())
+	streak := streaks.Val()
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Dice Stats",
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    u.DisplayName(),
+			IconURL: u.AvatarURL("64"),
+		},
+		Footer: makeEmbedFooter(),
+	}
+	if len(stats) == 0 {
+		embed.Description = "You haven't rolled any dice yet!"
+		return embed, nil
+	}
+
+	embed.Description = humanfmt.Sprintf("**%d** rolls made. Overall luck: **%+.1f**", rolls, overallLuck(stats))
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name: "Streaks",
+		Value: fmt.Sprintf("Current: %s hot, %s cold\nBest: %s hot, %s cold",
+			orZero(streak[StreakHot]), orZero(streak[StreakCold]),
+			orZero(streak["best_"+StreakHot]), orZero(streak["best_"+StreakCold])),
+	})
+
+	most := stats[0]
+	for _, s := range stats {
+		value := humanfmt.Sprintf("%d rolled\nAvg. %.2f (exp. %.1f)\n", s.Count(), s.Mean(), s.Expected())
+		if isStandardDie(s.Size) {
+			value += humanfmt.Sprintf("%d max, %d min\n", s.Faces[s.Size], s.Faces[1])
+		}
+		value += humanfmt.Sprintf("Luck %+.1f", s.Luck())
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("d%d", s.Size),
+			Value:  value,
+			Inline: true,
+		})
+		if s.Count() > most.Count() {
+			most = s
+		}
+	}
+	// Discord limits embeds to 25 fields
+	embed.Fields = trunc(embed.Fields, 24)
+
+	if most.Size <= 20 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("d%d Distribution", most.Size),
+			Value: markdownDistribution(most),
+		})
+	}
+	return embed, nil
+}
+
+func orZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// InteractionUserStats sends a user's personal dice luck statistics.
+func InteractionUserStats(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "mystats"}, 1)
+
+	embed, err := makeEmbedUserStats(ctx, UserFromInteraction(i))
+	if err != nil {
+		logger.Error("error getting user stats", zap.Error(err))
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	var flags discordgo.MessageFlags
+	if opt := getOptionByName(i.ApplicationCommandData().Options, "share"); opt == nil || !opt.BoolValue() {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:  flags,
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	}); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
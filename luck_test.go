@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/travis-g/dice"
+)
+
+func testRollerGroup(size int, values ...float64) *dice.RollerGroup {
+	group := &dice.RollerGroup{}
+	for _, v := range values {
+		group.Group = append(group.Group, &dice.Die{Size: size, Result: dice.NewResult(v)})
+	}
+	return group
+}
+
+func Test_countFaces(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []*dice.RollerGroup
+		want   map[string]int64
+	}{
+		{name: "empty", groups: nil, want: map[string]int64{}},
+		{name: "d20", groups: []*dice.RollerGroup{testRollerGroup(20, 20, 1, 20)}, want: map[string]int64{"20:20": 2, "20:1": 1}},
+		{name: "mixed", groups: []*dice.RollerGroup{testRollerGroup(6, 3), testRollerGroup(8, 3)}, want: map[string]int64{"6:3": 1, "8:3": 1}},
+		{name: "uncommon size", groups: []*dice.RollerGroup{testRollerGroup(1000000, 5, 700000)}, want: map[string]int64{"1000000:count": 2, "1000000:sum": 700005}},
+		{name: "fudge", groups: []*dice.RollerGroup{{Group: dice.Group{&dice.Die{Type: dice.TypeFudge, Size: 1, Result: dice.NewResult(-1)}}}}, want: map[string]int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countFaces(tt.groups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("countFaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_streakDirection(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []*dice.RollerGroup
+		want   string
+	}{
+		{name: "hot", groups: []*dice.RollerGroup{testRollerGroup(20, 15)}, want: StreakHot},
+		{name: "cold", groups: []*dice.RollerGroup{testRollerGroup(6, 1, 2)}, want: StreakCold},
+		{name: "even", groups: []*dice.RollerGroup{testRollerGroup(6, 1, 6)}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streakDirection(tt.groups); got != tt.want {
+				t.Errorf("streakDirection() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDieStats_Luck(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats *DieStats
+		want  float64
+	}{
+		{name: "max", stats: &DieStats{Size: 20, Faces: map[int]int64{20: 3}}, want: 100},
+		{name: "min", stats: &DieStats{Size: 6, Faces: map[int]int64{1: 1}}, want: -100},
+		{name: "expected", stats: &DieStats{Size: 6, Faces: map[int]int64{1: 1, 6: 1}}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.Luck(); got != tt.want {
+				t.Errorf("DieStats.Luck() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseDieStats(t *testing.T) {
+	got := parseDieStats(map[string]string{"20:1": "2", "6:6": "1", "bad": "1", "20:20": "4", "1000:count": "2", "1000:sum": "1500"})
+	want := []*DieStats{
+		{Size: 6, Faces: map[int]int64{6: 1}},
+		{Size: 20, Faces: map[int]int64{1: 2, 20: 4}},
+		{Size: 1000, Faces: map[int]int64{}, N: 2, Sum: 1500},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDieStats() = %v, want %v", got, want)
+	}
+}
@@ -100,6 +100,8 @@ func main() {
 		logger.Debug("commands", zap.Any("object", DiceGolem.Commands))
 	}()
 
+	go DiceGolem.RunScheduler(ctx)
+
 	// if DBL token is provided, set up the background server count updater.
 	if DiceGolem.TopToken != nil {
 		logger.Info("dbl enabled")
@@ -293,20 +295,13 @@ func RouteInteractionCreate(s *discordgo.Session, ic *discordgo.InteractionCreat
 		// if button was a macro button strip off the macro_ prefix and use the
 		// ID as the rest of the expression
 		if strings.HasPrefix(id, "macro_") {
-			roll := strings.TrimPrefix(id, "macro_")
-			_, response, _ := NewRollMessageResponseFromString(ctx, roll)
-			_, err := s.ChannelMessageSendComplex(i.ChannelID, response)
-			if err != nil {
-				MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrSendMessagePermissions.Error()))
-			} else {
-				// if we sent correctly, clear the pending button press
-				_ = MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
-					Type: discordgo.InteractionResponseDeferredMessageUpdate,
-				})
-			}
+			RollMacroInteraction(ctx, strings.TrimPrefix(id, "macro_"))
 		} else if handle, ok := handlers[id]; ok {
 			// if it was a generic action button, handle the press
 			handle(ctx)
+		} else if handle, ok := componentHandlers[strings.SplitN(id, ":", 2)[0]]; ok {
+			// if it was a button with arguments, let its handler parse them
+			handle(ctx)
 		} else {
 			// handler doesn't exist for sent command
 			if err := MeasureInteractionRespond(s.InteractionRespond, i,
@@ -339,7 +334,9 @@ func RouteInteractionCreate(s *discordgo.Session, ic *discordgo.InteractionCreat
 		data := i.ModalSubmitData()
 		logger.Debug("modal in", zap.Any("data", data))
 
-		switch data.CustomID {
+		// modals with dynamic custom IDs are handled by the name prefixing their
+		// arguments (see newComponentID)
+		switch name, _ := parseComponentID(data.CustomID); name {
 		case "modal_save":
 			data := getModalTextInputComponents(data)
 			roll := new(NamedRollInput)
@@ -363,6 +360,15 @@ func RouteInteractionCreate(s *discordgo.Session, ic *discordgo.InteractionCreat
 		case "modal_import":
 			ImportExpressionsInteraction(ctx, getModalTextInputComponents(data))
 			return
+		case "modal_table":
+			CreateTableModalInteraction(ctx, getModalTextInputComponents(data))
+			return
+		case "modal_contest":
+			ContestModalInteraction(ctx, getModalTextInputComponents(data))
+			return
+		case "modal_grouproll":
+			GroupRollModalInteraction(ctx, getModalTextInputComponents(data))
+			return
 
 		default:
 			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry! You submitted an unexpected modal. Please try again later."))
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/travis-g/dice"
+)
+
+// A NotationRoller rolls a dice notation the dice library can't evaluate on its
+// own, like success-counting pools. Matching notations are rolled and replaced
+// with their values before the remaining expression is evaluated.
+type NotationRoller struct {
+	Name   string
+	Regexp *regexp.Regexp
+	Roll   func(ctx context.Context, match []string) (*RolledNotation, error)
+}
+
+// notationRollers are the registered NotationRollers, in order of precedence.
+var notationRollers = []*NotationRoller{
+	{
+		Name:   "percentile",
+		Regexp: regexp.MustCompile(`(?i)\bd100([bp])(\d)`),
+		Roll:   rollPercentile,
+	},
+	{
+		Name:   "pool",
+		Regexp: regexp.MustCompile(`(?i)\b(\d*)d(\d+)(!)?(>=|<=|>|<|=)(\d+)(?:f(\d+))?`),
+		Roll:   rollPool,
+	},
+	{
+		Name:   "explode",
+		Regexp: regexp.MustCompile(`(?i)\b(\d*)d(\d+)(!!|!p|!)`),
+		Roll:   rollExplode,
+	},
+	{
+		Name:   "custom",
+		Regexp: customDiceRegexp,
+		Roll:   rollCustomDice,
+	},
+	{
+		Name:   "fate",
+		Regexp: regexp.MustCompile(`(?i)\b(\d*)dF\b`),
+		Roll:   rollFate,
+	},
+}
+
+// ExplodeMode is how a die's explosions are rolled and totaled.
+type ExplodeMode int
+
+// Explosion modes for dice notations.
+const (
+	// ExplodeNone does not explode dice.
+	ExplodeNone ExplodeMode = iota
+	// ExplodeStandard ("!") rolls an additional die for each maximum roll.
+	ExplodeStandard
+	// ExplodeCompound ("!!") adds explosions to the die that exploded.
+	ExplodeCompound
+	// ExplodePenetrate ("!p") rolls an additional die for each maximum roll,
+	// subtracting 1 from each additional die.
+	ExplodePenetrate
+)
+
+// explodeModes maps explosion notations to their modes.
+var explodeModes = map[string]ExplodeMode{
+	"":   ExplodeNone,
+	"!":  ExplodeStandard,
+	"!!": ExplodeCompound,
+	"!p": ExplodePenetrate,
+}
+
+// maxChainLength is the maximum number of dice that can be rolled for a
+// single exploding die.
+const maxChainLength = 100
+
+// RolledNotation is a notation rolled by a NotationRoller.
+type RolledNotation struct {
+	Notation string
+	Group    *dice.RollerGroup
+	Value    float64
+	Explode  ExplodeMode
+
+	// Success-counting pool results
+	Pool      bool
+	Successes int
+	Failures  int
+	Glitch    bool
+
+	// Symbols rolled on user-defined dice, with their counts
+	Symbols map[string]int
+
+	// highlight reports whether a die should be emphasized when rendered.
+	highlight func(*dice.Die) bool
+	// markdown renders the notation's dice in place of markdownGroup.
+	markdown func(ctx context.Context) string
+	// rolled is shown in place of the notation's value in the rolled
+	// expression, ex. Fate symbols.
+	rolled string
+}
+
+// Markdown renders the notation's dice in the format of MarkdownString.
+func (n *RolledNotation) Markdown(ctx context.Context) string {
+	var s string
+	if n.markdown != nil {
+		s = n.markdown(ctx)
+	} else {
+		s = "[" + markdownGroup(ctx, n.Group, n.Explode, n.highlight) + "]"
+	}
+	return fmt.Sprintf("%s ⇒ **%s**", s, n.String())
+}
+
+// String returns a short description of the notation's result, ex. "3
+// successes".
+func (n *RolledNotation) String() string {
+	if len(n.Symbols) > 0 {
+		return n.symbolString()
+	}
+	if !n.Pool {
+		return strconv.FormatFloat(n.Value, 'f', -1, 64)
+	}
+	s := pluralize(int(n.Value), "success", "successes")
+	if n.Glitch {
+		s += ", glitch!"
+	}
+	return s
+}
+
+// rollNotations rolls every notation in an expression that has a registered
+// NotationRoller, returning the expression with each notation replaced by its
+// value.
+func rollNotations(ctx context.Context, expression string) (string, []*RolledNotation, error) {
+	ctx = dice.NewContextFromContext(ctx)
+	var (
+		notations []*RolledNotation
+		errs      []error
+	)
+	for _, roller := range notationRollers {
+		expression = roller.Regexp.ReplaceAllStringFunc(expression, func(match string) string {
+			rolled, err := roller.Roll(ctx, roller.Regexp.FindStringSubmatch(match))
+			if err != nil {
+				errs = append(errs, err)
+				return match
+			}
+			rolled.Notation = match
+			notations = append(notations, rolled)
+			return rolledPlaceholder(rolled)
+		})
+	}
+	return expression, notations, errors.Join(errs...)
+}
+
+// rolledPlaceholder returns the value a notation is replaced with before the
+// expression is evaluated. Notations with rolled text are padded so they can
+// be told apart from dice groups when the rolled expression is rewritten.
+func rolledPlaceholder(n *RolledNotation) string {
+	value := strconv.FormatFloat(n.Value, 'f', -1, 64)
+	if n.rolled != "" {
+		return "( " + value + " )"
+	}
+	return "(" + value + ")"
+}
+
+// replaceRolledText replaces the placeholders of notations with rolled text in
+// a rolled expression, in the order the notations were rolled.
+func replaceRolledText(rolled string, notations []*RolledNotation) string {
+	for _, n := range notations {
+		if n.rolled != "" {
+			rolled = strings.Replace(rolled, rolledPlaceholder(n), "("+n.rolled+")", 1)
+		}
+	}
+	return rolled
+}
+
+// compare returns whether a value satisfies a comparison against a target.
+func compare(value float64, target *dice.CompareTarget) bool {
+	t := float64(target.Target)
+	switch target.Compare {
+	case dice.EQL:
+		return value == t
+	case dice.LSS:
+		return value < t
+	case dice.GTR:
+		return value > t
+	case dice.LEQ:
+		return value <= t
+	case dice.GEQ:
+		return value >= t
+	default:
+		return false
+	}
+}
+
+// addRoller appends a Roller to a group, setting the group as the parent of
+// dice. RollerGroups can't have parents set.
+func addRoller(group *dice.RollerGroup, r dice.Roller) {
+	if die, ok := r.(*dice.Die); ok {
+		die.SetParent(group)
+	}
+	group.Group = append(group.Group, r)
+}
+
+// rollChain rolls a die and any explosions it has. Each die that was rolled
+// is kept in the returned chain, in the order it was rolled.
+func rollChain(ctx context.Context, size int, mode ExplodeMode) (*dice.RollerGroup, error) {
+	if size < 1 || (mode != ExplodeNone && size < 2) {
+		return nil, dice.ErrImpossibleDie
+	}
+	chain := &dice.RollerGroup{}
+	for len(chain.Group) < maxChainLength {
+		die := &dice.Die{Size: size}
+		if err := die.Roll(ctx); err != nil {
+			return nil, err
+		}
+		addRoller(chain, die)
+		if mode == ExplodeNone || die.Result.Value != float64(size) {
+			break
+		}
+	}
+	return chain, nil
+}
+
+// chainValues returns the values that each die of a chain contributes to its
+// total. Penetrating explosions are 1 less than their rolls.
+func chainValues(chain *dice.RollerGroup, mode ExplodeMode) []float64 {
+	values := make([]float64, 0, len(chain.Group))
+	for i, roller := range chain.Group {
+		die, ok := roller.(*dice.Die)
+		if !ok || die.Result == nil {
+			continue
+		}
+		v := die.Result.Value
+		if mode == ExplodePenetrate && i > 0 {
+			v--
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// rollDice rolls a number of dice with a size, grouping each die with its
+// explosions.
+func rollDice(ctx context.Context, count, size int, mode ExplodeMode) (*dice.RollerGroup, error) {
+	group := &dice.RollerGroup{}
+	for i := 0; i < count; i++ {
+		chain, err := rollChain(ctx, size, mode)
+		if err != nil {
+			return nil, err
+		}
+		if mode == ExplodeNone {
+			addRoller(group, chain.Group[0])
+		} else {
+			addRoller(group, chain)
+		}
+	}
+	return group, nil
+}
+
+// rollExplode rolls exploding dice like "3d6!", compounding dice like "3d6!!",
+// or penetrating dice like "3d6!p".
+func rollExplode(ctx context.Context, match []string) (*RolledNotation, error) {
+	count := 1
+	if match[1] != "" {
+		count, _ = strconv.Atoi(match[1])
+	}
+	size, _ := strconv.Atoi(match[2])
+	mode := explodeModes[strings.ToLower(match[3])]
+	group, err := rollDice(ctx, count, size, mode)
+	if err != nil {
+		return nil, err
+	}
+	n := &RolledNotation{
+		Group:   group,
+		Explode: mode,
+	}
+	for _, roller := range group.Group {
+		for _, v := range chainValues(roller.(*dice.RollerGroup), mode) {
+			n.Value += v
+		}
+	}
+	return n, nil
+}
+
+// rollPool rolls a success-counting dice pool like "10d10>=8", with optional
+// explosions on the maximum face ("!") and subtraction of failures ("f1").
+// Each exploded die counts towards the pool's successes.
+func rollPool(ctx context.Context, match []string) (*RolledNotation, error) {
+	count := 1
+	if match[1] != "" {
+		count, _ = strconv.Atoi(match[1])
+	}
+	size, _ := strconv.Atoi(match[2])
+	mode := explodeModes[match[3]]
+	target := &dice.CompareTarget{Compare: dice.LookupCompareOp(match[4])}
+	target.Target, _ = strconv.Atoi(match[5])
+	failOn := 0
+	if match[6] != "" {
+		failOn, _ = strconv.Atoi(match[6])
+	}
+
+	group, err := rollDice(ctx, count, size, mode)
+	if err != nil {
+		return nil, err
+	}
+	n := &RolledNotation{
+		Group:   group,
+		Explode: mode,
+		Pool:    true,
+	}
+	ones, total := 0, 0
+	eachDie([]*dice.RollerGroup{group}, func(d *dice.Die) {
+		switch {
+		case compare(d.Result.Value, target):
+			n.Successes++
+		case d.Result.Value <= float64(failOn):
+			n.Failures++
+		}
+		if d.Result.Value == 1 {
+			ones++
+		}
+		total++
+	})
+	n.Value = float64(n.Successes - n.Failures)
+	n.Glitch = ones*2 > total
+	n.highlight = func(d *dice.Die) bool {
+		return compare(d.Result.Value, target)
+	}
+	return n, nil
+}
+
+// pluralize formats a count with the singular or plural form of a noun.
+func pluralize(n int, singular, plural string) string {
+	if n == 1 || n == -1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// markdownGroup converts a dice group into a Markdown-compatible list of
+// values. Dropped dice are struck through, highlighted dice are bolded,
+// exploded dice are shown as chains, and Fate dice are shown as symbols.
+func markdownGroup(ctx context.Context, group *dice.RollerGroup, mode ExplodeMode, highlight func(*dice.Die) bool) string {
+	var b strings.Builder
+	write := b.WriteString
+	for _, roller := range group.Group.Copy() {
+		if chain, ok := roller.(*dice.RollerGroup); ok {
+			write(markdownChain(chain, mode, highlight))
+			write(", ")
+			continue
+		}
+		val, _ := roller.Value(ctx)
+		sval := strconv.FormatFloat(val, 'f', -1, 64)
+		die, isDie := roller.(*dice.Die)
+		if isDie && die.Type == dice.TypeFudge {
+			sval = fateSymbol(val)
+		}
+		switch {
+		case roller.IsDropped(ctx):
+			write("~~" + sval + "~~")
+		case highlight != nil && isDie && highlight(die):
+			write("**" + sval + "**")
+		default:
+			write(sval)
+		}
+		write(", ")
+	}
+	return strings.TrimSuffix(b.String(), ", ")
+}
+
+// markdownChain renders an exploded die's chain of rolls, ex. "6→6→3".
+// Compounded chains are rendered as their total followed by the chain.
+func markdownChain(chain *dice.RollerGroup, mode ExplodeMode, highlight func(*dice.Die) bool) string {
+	values := chainValues(chain, mode)
+	parts := make([]string, len(values))
+	total := 0.0
+	for i, v := range values {
+		total += v
+		parts[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		if die, ok := chain.Group[i].(*dice.Die); ok && highlight != nil && highlight(die) {
+			parts[i] = "**" + parts[i] + "**"
+		}
+	}
+	s := strings.Join(parts, "\u2192")
+	if mode == ExplodeCompound && len(values) > 1 {
+		s = fmt.Sprintf("%s (%s)", strconv.FormatFloat(total, 'f', -1, 64), s)
+	}
+	return s
+}
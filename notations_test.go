@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/travis-g/dice"
+)
+
+func testNotationRegexp(name string) *regexp.Regexp {
+	for _, roller := range notationRollers {
+		if roller.Name == name {
+			return roller.Regexp
+		}
+	}
+	panic("no notation roller " + name)
+}
+
+func Test_compare(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  float64
+		target *dice.CompareTarget
+		want   bool
+	}{
+		{name: "geq", value: 8, target: &dice.CompareTarget{Compare: dice.GEQ, Target: 8}, want: true},
+		{name: "gtr", value: 8, target: &dice.CompareTarget{Compare: dice.GTR, Target: 8}, want: false},
+		{name: "leq", value: 3, target: &dice.CompareTarget{Compare: dice.LEQ, Target: 3}, want: true},
+		{name: "lss", value: 3, target: &dice.CompareTarget{Compare: dice.LSS, Target: 3}, want: false},
+		{name: "eql", value: 6, target: &dice.CompareTarget{Compare: dice.EQL, Target: 6}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compare(tt.value, tt.target); got != tt.want {
+				t.Errorf("compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pluralize(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{n: 0, want: "0 successes"},
+		{n: 1, want: "1 success"},
+		{n: -1, want: "-1 success"},
+		{n: 3, want: "3 successes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := pluralize(tt.n, "success", "successes"); got != tt.want {
+				t.Errorf("pluralize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rollNotations(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       string
+		result     string
+		wantErr    bool
+	}{
+		// d1s always roll 1, so pools of them are deterministic
+		{name: "pool", expression: "3d1>=1", want: "(3)", result: "3 successes, glitch!"},
+		{name: "math", expression: "2d1>1 + 2", want: "(0) + 2", result: "0 successes, glitch!"},
+		{name: "plain", expression: "3d6+2", want: "3d6+2"},
+		{name: "impossible", expression: "3d1!>=1", want: "3d1!>=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, notations, err := rollNotations(context.Background(), tt.expression)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rollNotations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("rollNotations() = %v, want %v", got, tt.want)
+			}
+			if tt.result != "" && (len(notations) != 1 || notations[0].String() != tt.result) {
+				t.Errorf("rollNotations() notations = %v, want %v", notations, tt.result)
+			}
+		})
+	}
+}
+
+func Test_markdownChain(t *testing.T) {
+	tests := []struct {
+		name  string
+		chain *dice.RollerGroup
+		mode  ExplodeMode
+		want  string
+	}{
+		{name: "single", chain: testRollerGroup(6, 4), mode: ExplodeStandard, want: "4"},
+		{name: "exploded", chain: testRollerGroup(6, 6, 6, 3), mode: ExplodeStandard, want: "6→6→3"},
+		{name: "compounded", chain: testRollerGroup(6, 6, 6, 3), mode: ExplodeCompound, want: "15 (6→6→3)"},
+		{name: "compounded single", chain: testRollerGroup(6, 2), mode: ExplodeCompound, want: "2"},
+		{name: "penetrated", chain: testRollerGroup(6, 6, 6, 3), mode: ExplodePenetrate, want: "6→5→2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdownChain(tt.chain, tt.mode, nil); got != tt.want {
+				t.Errorf("markdownChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rollExplode(t *testing.T) {
+	ctx := dice.NewContextFromContext(context.Background())
+	for _, notation := range []string{"4d6!", "4d6!!", "4d6!p", "d2!"} {
+		t.Run(notation, func(t *testing.T) {
+			rolled, err := rollExplode(ctx, testNotationRegexp("explode").FindStringSubmatch(notation))
+			if err != nil {
+				t.Fatalf("rollExplode() error = %v", err)
+			}
+			sum := 0.0
+			for _, roller := range rolled.Group.Group {
+				for _, v := range chainValues(roller.(*dice.RollerGroup), rolled.Explode) {
+					sum += v
+				}
+			}
+			if sum != rolled.Value {
+				t.Errorf("rollExplode() value = %v, want sum of chains %v", rolled.Value, sum)
+			}
+		})
+	}
+	if _, err := rollExplode(ctx, testNotationRegexp("explode").FindStringSubmatch("3d1!")); err == nil {
+		t.Errorf("rollExplode() of d1s should error")
+	}
+}
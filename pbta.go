@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// Guild setting value fields of Powered by the Apocalypse outcome bands.
+const (
+	SettingPbtaStrong = "pbta:strong"
+	SettingPbtaWeak   = "pbta:weak"
+)
+
+// PbtaBands are the minimum totals of a Powered by the Apocalypse move's
+// outcomes. Totals below Weak are misses.
+type PbtaBands struct {
+	Strong int
+	Weak   int
+}
+
+// DefaultPbtaBands are the usual bands: 10+ strong hit, 7-9 weak hit, 6- miss.
+var DefaultPbtaBands = PbtaBands{Strong: 10, Weak: 7}
+
+// Valid returns whether the bands are in order.
+func (b PbtaBands) Valid() bool {
+	return b.Weak < b.Strong
+}
+
+// Interpret interprets the result of a move using the bands.
+func (b PbtaBands) Interpret(res *Response) string {
+	total := res.ExpressionResult.Result
+	switch {
+	case total >= float64(b.Strong):
+		return "Strong hit"
+	case total >= float64(b.Weak):
+		return "Weak hit"
+	default:
+		return "Miss"
+	}
+}
+
+func (b PbtaBands) String() string {
+	return fmt.Sprintf("%d+ strong hit, %d-%d weak hit, %d- miss", b.Strong, b.Weak, b.Strong-1, b.Weak-1)
+}
+
+// GetPbtaBands returns a guild's configured outcome bands, or the defaults.
+func GetPbtaBands(ctx context.Context, gid string) PbtaBands {
+	bands := DefaultPbtaBands
+	if gid == "" || DiceGolem.Cache.Redis == nil {
+		return bands
+	}
+	vals, err := DiceGolem.Cache.Redis.HMGet(ctx, fmt.Sprintf(KeyGuildValuesFmt, gid), SettingPbtaStrong, SettingPbtaWeak).Result()
+	if err != nil {
+		logger.Error("error getting pbta bands", zap.Error(err))
+		return bands
+	}
+	if v, ok := vals[0].(string); ok {
+		bands.Strong, _ = strconv.Atoi(v)
+	}
+	if v, ok := vals[1].(string); ok {
+		bands.Weak, _ = strconv.Atoi(v)
+	}
+	if !bands.Valid() {
+		return DefaultPbtaBands
+	}
+	return bands
+}
+
+// SetPbtaBands persists a guild's outcome bands.
+func SetPbtaBands(ctx context.Context, gid string, bands PbtaBands) error {
+	if DiceGolem.Cache.Redis == nil {
+		return ErrNoRedisClient
+	}
+	return DiceGolem.Cache.Redis.HSet(ctx, fmt.Sprintf(KeyGuildValuesFmt, gid),
+		SettingPbtaStrong, bands.Strong,
+		SettingPbtaWeak, bands.Weak,
+	).Err()
+}
+
+// pbtaExpression builds the expression for a move rolled with a stat.
+func pbtaExpression(stat int, move string) string {
+	expression := "2d6"
+	if stat != 0 {
+		expression += fmt.Sprintf("%+d", stat)
+	}
+	if move != "" {
+		expression += " # " + move
+	}
+	return expression
+}
+
+// InteractionPbta rolls a Powered by the Apocalypse move.
+func InteractionPbta(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "pbta"}, 1)
+
+	options := i.ApplicationCommandData().Options
+	var (
+		stat int
+		move string
+	)
+	if opt := getOptionByName(options, "stat"); opt != nil {
+		stat = int(opt.IntValue())
+	}
+	if opt := getOptionByName(options, "move"); opt != nil {
+		move = opt.StringValue()
+	}
+
+	bands := GetPbtaBands(ctx, i.GuildID)
+	message, response, err := NewRollInteractionResponseFromStringWithContext(
+		WithResultInterpreter(ctx, bands.Interpret), pbtaExpression(stat, move))
+	if opt := getOptionByName(options, "secret"); opt != nil && opt.BoolValue() {
+		response.Data.Flags |= discordgo.MessageFlagsEphemeral
+	}
+	if err == nil {
+		defer CacheRoll(UserFromInteraction(i), &NamedRollInput{
+			Expression: message.Expression,
+			Label:      message.Label,
+		})
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, response); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
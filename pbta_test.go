@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/travis-g/dice/math"
+)
+
+func TestPbtaBands_Interpret(t *testing.T) {
+	tests := []struct {
+		name  string
+		bands PbtaBands
+		total float64
+		want  string
+	}{
+		{name: "strong", bands: DefaultPbtaBands, total: 10, want: "Strong hit"},
+		{name: "weak high", bands: DefaultPbtaBands, total: 9, want: "Weak hit"},
+		{name: "weak low", bands: DefaultPbtaBands, total: 7, want: "Weak hit"},
+		{name: "miss", bands: DefaultPbtaBands, total: 6, want: "Miss"},
+		{name: "custom strong", bands: PbtaBands{Strong: 12, Weak: 8}, total: 11, want: "Weak hit"},
+		{name: "custom miss", bands: PbtaBands{Strong: 12, Weak: 8}, total: 7, want: "Miss"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &Response{ExpressionResult: &math.ExpressionResult{Result: tt.total}}
+			if got := tt.bands.Interpret(res); got != tt.want {
+				t.Errorf("PbtaBands.Interpret() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPbtaBands_String(t *testing.T) {
+	if got, want := DefaultPbtaBands.String(), "10+ strong hit, 7-9 weak hit, 6- miss"; got != want {
+		t.Errorf("PbtaBands.String() = %v, want %v", got, want)
+	}
+}
+
+func Test_pbtaExpression(t *testing.T) {
+	tests := []struct {
+		stat int
+		move string
+		want string
+	}{
+		{stat: 0, want: "2d6"},
+		{stat: 2, move: "Defy Danger", want: "2d6+2 # Defy Danger"},
+		{stat: -1, want: "2d6-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := pbtaExpression(tt.stat, tt.move); got != tt.want {
+				t.Errorf("pbtaExpression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Constant keys and fmt string formats for roll receipts.
+const (
+	KeyReceiptSecret = "state:receipts:key"
+	KeyReceiptFmt    = "receipt:%s"
+)
+
+// receiptTokenLength is the length of receipt tokens, in characters.
+const receiptTokenLength = 16
+
+// Receipt is a signed record of a roll the bot evaluated, used to verify that
+// a shared result is genuine.
+type Receipt struct {
+	User       string `json:"user"`
+	Expression string `json:"expression"`
+	Label      string `json:"label,omitempty"`
+	Rolled     string `json:"rolled"`
+	Dice       string `json:"dice"`
+	Result     string `json:"result"`
+	Time       int64  `json:"time"`
+}
+
+// Sign returns the receipt's token: a truncated, URL-safe HMAC-SHA256 of the
+// receipt's fields.
+func (r *Receipt) Sign(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	// fields are joined with a delimiter that can't appear in a roll
+	mac.Write([]byte(strings.Join([]string{
+		r.User, r.Expression, r.Label, r.Rolled, r.Dice, r.Result,
+		strconv.FormatInt(r.Time, 10),
+	}, "\x00")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))[:receiptTokenLength]
+}
+
+// Verify returns whether the token was signed for the receipt.
+func (r *Receipt) Verify(key []byte, token string) bool {
+	return hmac.Equal([]byte(r.Sign(key)), []byte(token))
+}
+
+// setupReceiptKey loads the shared receipt signing key from Redis, creating a
+// random key if no process has set one yet.
+func setupReceiptKey(ctx context.Context, c *redis.Client) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	if err := c.SetNX(ctx, KeyReceiptSecret, hex.EncodeToString(secret), 0).Err(); err != nil {
+		return "", err
+	}
+	return c.Get(ctx, KeyReceiptSecret).Result()
+}
+
+// IssueReceipt signs and stores a receipt for a roll response, returning its
+// token.
+func IssueReceipt(ctx context.Context, uid string, res *Response) (string, error) {
+	if DiceGolem.Cache.Redis == nil {
+		return "", ErrNoRedisClient
+	}
+	if DiceGolem.ReceiptKey == "" {
+		return "", ErrNotImplemented
+	}
+	receipt := &Receipt{
+		User:       uid,
+		Expression: res.Expression,
+		Label:      res.Label,
+		Rolled:     res.Rolled,
+		Result:     res.Result,
+		Dice:       plainDetails(res),
+		Time:       time.Now().UnixMilli(),
+	}
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		return "", err
+	}
+	token := receipt.Sign([]byte(DiceGolem.ReceiptKey))
+
+	defer metrics.MeasureSince([]string{"redis", "issue_receipt"}, time.Now())
+	if err := DiceGolem.Cache.Redis.Set(ctx, fmt.Sprintf(KeyReceiptFmt, token), data, DiceGolem.DataTTL).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetReceipt returns the stored receipt for a token if it exists and its
+// signature is valid.
+func GetReceipt(ctx context.Context, token string) (*Receipt, error) {
+	if DiceGolem.Cache.Redis == nil {
+		return nil, ErrNoRedisClient
+	}
+	data, err := DiceGolem.Cache.Redis.Get(ctx, fmt.Sprintf(KeyReceiptFmt, token)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	receipt := new(Receipt)
+	if err := json.Unmarshal(data, receipt); err != nil {
+		return nil, err
+	}
+	if !receipt.Verify([]byte(DiceGolem.ReceiptKey), token) {
+		return nil, redis.Nil
+	}
+	return receipt, nil
+}
+
+// makeVerifyButton creates a button that verifies a roll's receipt.
+func makeVerifyButton(token string) discordgo.MessageComponent {
+	return discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Verify",
+				Style:    discordgo.SecondaryButton,
+				CustomID: newComponentID("verify", token),
+			},
+		},
+	}
+}
+
+// verifyReceiptResponse looks up a receipt token and creates an ephemeral
+// response describing the genuine roll, if any.
+func verifyReceiptResponse(ctx context.Context, token string) *discordgo.InteractionResponse {
+	token = strings.Trim(strings.TrimSpace(token), "`")
+	receipt, err := GetReceipt(ctx, token)
+	if err != nil {
+		if err != redis.Nil {
+			logger.Error("error getting receipt", zap.Error(err))
+		}
+		return newEphemeralResponse(fmt.Sprintf("No roll was found for receipt `%s`. It may have expired or been mistyped.", token))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Receipt `%s` is genuine: <@%s> rolled `%s`", token, receipt.User, receipt.Expression)
+	if receipt.Label != "" {
+		fmt.Fprintf(&b, " _%s_", receipt.Label)
+	}
+	fmt.Fprintf(&b, ": `%s` = **%s** <t:%d:f>", receipt.Rolled, receipt.Result, time.UnixMilli(receipt.Time).Unix())
+	if receipt.Dice != "" {
+		fmt.Fprintf(&b, "\n-# Dice: %s", receipt.Dice)
+	}
+	return newEphemeralResponse(b.String())
+}
+
+// InteractionVerify verifies a roll receipt token.
+func InteractionVerify(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "verify"}, 1)
+	code := mustGetOptionByName(i.ApplicationCommandData().Options, "code").StringValue()
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, verifyReceiptResponse(ctx, code)); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
+
+// InteractionVerifyComponent handles presses of a roll's Verify button.
+func InteractionVerifyComponent(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "verify"}, 1)
+	_, args := parseComponentID(i.MessageComponentData().CustomID)
+	if len(args) == 0 {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidCommand.Error()))
+		return
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, verifyReceiptResponse(ctx, args[0])); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestReceipt_Verify(t *testing.T) {
+	key := []byte("secret")
+	receipt := &Receipt{User: "1", Expression: "1d20", Rolled: "[17]", Dice: "[17]", Result: "17", Time: 1700000000000}
+	token := receipt.Sign(key)
+	if len(token) != receiptTokenLength {
+		t.Fatalf("Receipt.Sign() length = %d, want %d", len(token), receiptTokenLength)
+	}
+
+	tests := []struct {
+		name    string
+		receipt *Receipt
+		key     []byte
+		want    bool
+	}{
+		{name: "genuine", receipt: receipt, key: key, want: true},
+		{name: "edited result", receipt: &Receipt{User: "1", Expression: "1d20", Rolled: "[20]", Dice: "[17]", Result: "20", Time: 1700000000000}, key: key, want: false},
+		{name: "other user", receipt: &Receipt{User: "2", Expression: "1d20", Rolled: "[17]", Dice: "[17]", Result: "17", Time: 1700000000000}, key: key, want: false},
+		{name: "wrong key", receipt: receipt, key: []byte("other"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.receipt.Verify(tt.key, token); got != tt.want {
+				t.Errorf("Receipt.Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,16 +1,18 @@
 package main
 
 import (
+	"context"
 	"strings"
 	"text/template"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/travis-g/dice"
 	"github.com/travis-g/dice/math"
 )
 
 // Response templates for dice roll message responses.
 var (
-	ResponseTemplate = "{{if .Name}}{{.Name}} rolled{{end}}{{if .Expression}} `{{.Expression}}`{{end}}{{if .Label}} _{{.Label}}_{{end}}: `{{.Rolled}}` = **{{.Result}}**"
+	ResponseTemplate = "{{if .Name}}{{.Name}} rolled{{end}}{{if .Expression}} `{{.Expression}}`{{end}}{{if .Label}} _{{.Label}}_{{end}}: `{{.Rolled}}` = **{{.Result}}**{{if .Outcome}}\n**{{.Outcome}}**{{end}}{{if .Receipt}}\n-# Receipt `{{.Receipt}}`{{end}}"
 )
 
 var (
@@ -23,16 +25,67 @@ var (
 type Response struct {
 	*math.ExpressionResult
 	// Name of who made the roll (optional)
-	Name          string
-	Rolled        string
-	Result        string
-	Expression    string
-	Label         string
+	Name       string
+	Rolled     string
+	Result     string
+	Expression string
+	Label      string
+	// Notations rolled outside of the expression evaluator (optional)
+	Notations []*RolledNotation
+	// Outcome of the result for a game system (optional)
+	Outcome string
+	// Token of the roll's signed receipt (optional)
+	Receipt       string
 	FriendlyError error
 
 	Error error
 }
 
+// Groups returns every dice group rolled for the response, including the
+// groups of rolled notations.
+func (r *Response) Groups() []*dice.RollerGroup {
+	var groups []*dice.RollerGroup
+	for _, n := range r.Notations {
+		groups = append(groups, n.Group)
+	}
+	if r.ExpressionResult != nil {
+		groups = append(groups, r.ExpressionResult.Dice...)
+	}
+	return groups
+}
+
+// A ResultInterpreter reads the result of a roll for a game system, returning
+// the roll's outcome, ex. "Strong hit". An empty outcome is not shown.
+type ResultInterpreter func(res *Response) string
+
+// WithResultInterpreter returns a child context whose rolls are interpreted by
+// fn. A nil fn disables interpretation of a parent context's rolls.
+func WithResultInterpreter(ctx context.Context, fn ResultInterpreter) context.Context {
+	return context.WithValue(ctx, KeyResultInterpreter, fn)
+}
+
+// interpretResult sets a response's outcome using the context's
+// ResultInterpreter. If the context has none, a default interpreter for the
+// roll's dice is used, if any.
+func interpretResult(ctx context.Context, res *Response) {
+	fn, ok := ctx.Value(KeyResultInterpreter).(ResultInterpreter)
+	if !ok {
+		fn = defaultResultInterpreter(res)
+	}
+	if fn != nil {
+		res.Outcome = fn(res)
+	}
+}
+
+// defaultResultInterpreter returns the interpreter for rolls made without a
+// game system command, based on the dice rolled.
+func defaultResultInterpreter(res *Response) ResultInterpreter {
+	if isFateRoll(res.Groups()) {
+		return fateLadderInterpreter
+	}
+	return nil
+}
+
 func executeResponseTemplate(b *strings.Builder, r *Response) {
 	_ = responseResultTemplateCompiled.Execute(b, r)
 }
@@ -109,7 +109,7 @@ func EvaluateRollInputWithContext(ctx context.Context, rollInput *NamedRollInput
 		zap.Int("shard", s.ShardID),
 	)
 
-	res.ExpressionResult, err = evaluateRoll(ctx, res.Expression)
+	res.ExpressionResult, res.Notations, err = evaluateRoll(ctx, res.Expression)
 	if err != nil {
 		logger.Error("evaluation error",
 			zap.String("expression", res.Expression),
@@ -123,19 +123,56 @@ func EvaluateRollInputWithContext(ctx context.Context, rollInput *NamedRollInput
 	}
 	logger.Debug("evaluated roll", zap.Any("response", res))
 
-	go trackRollFromContext(ctx)
+	go trackRollFromContext(ctx, res.Groups())
 
 	res.Rolled = res.ExpressionResult.Rolled
 	res.Result = strconv.FormatFloat(res.ExpressionResult.Result, 'f', -1, 64)
+	// a lone pool reports its successes, and lone custom dice their symbols,
+	// instead of a sum
+	if len(res.Notations) == 1 && (res.Notations[0].Pool || len(res.Notations[0].Symbols) > 0) &&
+		res.ExpressionResult.Result == res.Notations[0].Value {
+		res.Result = res.Notations[0].String()
+	}
+	interpretResult(ctx, res)
+
+	if DiceGolem.ReceiptKey != "" {
+		uid, _, _ := idsFromContext(ctx)
+		if token, err := IssueReceipt(ctx, uid, res); err != nil {
+			logger.Error("error issuing receipt", zap.Error(err))
+		} else {
+			res.Receipt = token
+		}
+	}
+
+	go RecordRollEventFromContext(ctx, id, rollInput, res)
+	go CacheHistoryFromContext(ctx, &HistoryEntry{
+		Expression: res.Expression,
+		Label:      res.Label,
+		Rolled:     res.Rolled,
+		Result:     res.Result,
+		Channel:    cid,
+		ID:         id,
+	})
 	return
 }
 
-// evaluateRoll executes the given roll string and emits metrics.
-func evaluateRoll(ctx context.Context, roll string) (*math.ExpressionResult, error) {
+// evaluateRoll executes the given roll string and emits metrics. Notations with
+// registered NotationRollers are rolled first.
+func evaluateRoll(ctx context.Context, roll string) (*math.ExpressionResult, []*RolledNotation, error) {
 	defer metrics.MeasureSince([]string{"roll", "evaluate"}, time.Now())
+	ctx = withCustomDice(ctx, roll)
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
 	defer cancel()
-	return math.EvaluateExpression(ctx, roll)
+	expression, notations, err := rollNotations(ctx, roll)
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := math.EvaluateExpression(ctx, expression)
+	if res != nil {
+		res.Original = roll
+		res.Rolled = replaceRolledText(res.Rolled, notations)
+	}
+	return res, notations, err
 }
 
 func splitMultirollString(s string) []string {
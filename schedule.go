@@ -0,0 +1,446 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/redis/go-redis/v9"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// Limits of scheduled rolls.
+const (
+	maxScheduledRolls   = 25
+	minScheduleInterval = time.Hour
+	maxScheduleAhead    = 366 * 24 * time.Hour
+
+	// scheduleTick is how often due rolls are checked for, and scheduleLockTTL
+	// how long a process holds a due roll while firing it.
+	scheduleTick    = 30 * time.Second
+	scheduleLockTTL = 5 * time.Minute
+)
+
+// Errors for scheduled rolls.
+var (
+	ErrInvalidScheduleTime     = errors.New("Sorry! That time couldn't be read. Try a time like `18:30` or `2024-01-31 18:30` (UTC), a delay like `2h` or `1d`, or a Discord timestamp.")
+	ErrInvalidScheduleInterval = fmt.Errorf("Repeating rolls need an interval of at least %s, like `1d` or `1w`.", minScheduleInterval)
+	ErrTooManyScheduledRolls   = fmt.Errorf("Servers can only have up to %d scheduled rolls.", maxScheduledRolls)
+)
+
+// discordTimestampRegexp matches Discord timestamps, ex. "<t:1700000000:R>".
+var discordTimestampRegexp = regexp.MustCompile(`^<t:(\d+)(?::[a-zA-Z])?>$`)
+
+// A ScheduledRoll is a roll posted to a channel at a set time, and optionally
+// repeated at an interval.
+type ScheduledRoll struct {
+	ID         string        `json:"id"`
+	Owner      string        `json:"owner"`
+	Guild      string        `json:"guild"`
+	Channel    string        `json:"channel"`
+	Expression string        `json:"expression"`
+	Label      string        `json:"label,omitempty"`
+	Next       int64         `json:"next"`
+	Every      time.Duration `json:"every,omitempty"`
+}
+
+// parseScheduleDuration parses a duration that may also be in days or weeks,
+// ex. "90m", "1d" or "2w".
+func parseScheduleDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// parseScheduleTime parses when a roll is scheduled for: a delay from now, a
+// time of day in UTC, a date and time in UTC, or a Discord timestamp.
+func parseScheduleTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	var t time.Time
+	if match := discordTimestampRegexp.FindStringSubmatch(s); match != nil {
+		unix, _ := strconv.ParseInt(match[1], 10, 64)
+		t = time.Unix(unix, 0)
+	} else if d, err := parseScheduleDuration(s); err == nil {
+		t = now.Add(d)
+	} else if clock, err := time.Parse("15:04", s); err == nil {
+		y, m, d := now.UTC().Date()
+		t = time.Date(y, m, d, clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+	} else if t, err = parseSince(s); err != nil {
+		return time.Time{}, ErrInvalidScheduleTime
+	}
+	if !t.After(now) || t.Sub(now) > maxScheduleAhead {
+		return time.Time{}, ErrInvalidScheduleTime
+	}
+	return t, nil
+}
+
+// nextScheduleTime returns the next time a repeating roll fires after now, or
+// the zero time if it doesn't repeat.
+func nextScheduleTime(next time.Time, every time.Duration, now time.Time) time.Time {
+	if every <= 0 {
+		return time.Time{}
+	}
+	for !next.After(now) {
+		next = next.Add(every)
+	}
+	return next
+}
+
+// describeScheduledRoll renders a scheduled roll, ex. "`1d20` in #general
+// <t:1700000000:R>, every 24h0m0s".
+func describeScheduledRoll(job *ScheduledRoll) string {
+	var b strings.Builder
+	if job.Label != "" {
+		fmt.Fprintf(&b, "**%s** ", job.Label)
+	}
+	fmt.Fprintf(&b, "`%s` in <#%s> <t:%d:R>", job.Expression, job.Channel, job.Next)
+	if job.Every > 0 {
+		fmt.Fprintf(&b, ", every %s", job.Every)
+	}
+	return b.String()
+}
+
+// GetScheduledRolls returns the scheduled rolls of a guild, in the order they
+// next fire.
+func GetScheduledRolls(ctx context.Context, gid string) ([]*ScheduledRoll, error) {
+	ids, err := DiceGolem.Cache.Redis.SMembers(ctx, fmt.Sprintf(KeyGuildScheduleFmt, gid)).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*ScheduledRoll, 0, len(ids))
+	for _, id := range ids {
+		if job, err := GetScheduledRoll(ctx, id); err == nil {
+			jobs = append(jobs, job)
+		}
+	}
+	slices.SortFunc(jobs, func(a, b *ScheduledRoll) int { return cmp.Compare(a.Next, b.Next) })
+	return jobs, nil
+}
+
+// GetScheduledRoll returns a scheduled roll.
+func GetScheduledRoll(ctx context.Context, id string) (*ScheduledRoll, error) {
+	data, err := DiceGolem.Cache.Redis.Get(ctx, fmt.Sprintf(KeyScheduleJobFmt, id)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	job := new(ScheduledRoll)
+	return job, json.Unmarshal(data, job)
+}
+
+// SetScheduledRoll saves a scheduled roll and queues it for its next time.
+func SetScheduledRoll(ctx context.Context, job *ScheduledRoll) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = DiceGolem.Cache.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, fmt.Sprintf(KeyScheduleJobFmt, job.ID), data, 0)
+		pipe.SAdd(ctx, fmt.Sprintf(KeyGuildScheduleFmt, job.Guild), job.ID)
+		pipe.ZAdd(ctx, KeyScheduleDue, redis.Z{Score: float64(job.Next), Member: job.ID})
+		return nil
+	})
+	return err
+}
+
+// deleteScheduledRollScript deletes a scheduled roll only if it's one of the
+// guild's, returning 1 if it was deleted.
+var deleteScheduledRollScript = redis.NewScript(`
+if redis.call("SREM", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("DEL", KEYS[2])
+redis.call("ZREM", KEYS[3], ARGV[1])
+return 1
+`)
+
+// DeleteScheduledRoll deletes a scheduled roll of a guild, returning whether it
+// existed. Rolls scheduled in other guilds are left alone.
+func DeleteScheduledRoll(ctx context.Context, gid, id string) (bool, error) {
+	n, err := deleteScheduledRollScript.Run(ctx, DiceGolem.Cache.Redis, []string{
+		fmt.Sprintf(KeyGuildScheduleFmt, gid),
+		fmt.Sprintf(KeyScheduleJobFmt, id),
+		KeyScheduleDue,
+	}, id).Int()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// rollScheduledRoll rolls a scheduled roll and renders its message. Expressions
+// with inline rolls like "[[table:weather]]" are expanded the same as table
+// entries, and other expressions are rolled as-is.
+func rollScheduledRoll(ctx context.Context, job *ScheduledRoll) (string, error) {
+	var b strings.Builder
+	b.WriteString("⏰ ")
+	if job.Label != "" {
+		fmt.Fprintf(&b, "**%s**: ", job.Label)
+	}
+	if tableInlineRegexp.MatchString(job.Expression) {
+		expanded, err := expandTableResult(ctx, job.Expression, tableLookup(ctx, job.Owner, job.Guild), 0)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(expanded)
+		return truncString(b.String(), 2000), nil
+	}
+	res, _, err := evaluateRoll(ctx, job.Expression)
+	if err == nil && res == nil {
+		err = ErrNilExpressionResult
+	}
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&b, "`%s`: `%s` = **%v**", job.Expression, res.Rolled, res.Result)
+	return truncString(b.String(), 2000), nil
+}
+
+// newScheduleContext creates a context to roll a scheduled roll in, as if its
+// owner had rolled it in its channel.
+func newScheduleContext(ctx context.Context, s *discordgo.Session, job *ScheduledRoll) context.Context {
+	ctx = NewContext(ctx, s, &discordgo.Interaction{
+		ChannelID: job.Channel,
+		GuildID:   job.Guild,
+		User:      &discordgo.User{ID: job.Owner},
+	}, nil)
+	return context.WithValue(ctx, dice.CtxKeyMaxRolls, int(float64(DiceGolem.MaxDice)*1.1))
+}
+
+// RunScheduler fires scheduled rolls as they come due, and closes group rolls
+// that timed out, until the context is done. Every process runs a scheduler,
+// and each due roll is locked so only one of them fires it.
+func (b *Bot) RunScheduler(ctx context.Context) {
+	if b.Cache == nil || b.Cache.Redis == nil || len(b.Sessions) == 0 {
+		return
+	}
+	ticker := time.NewTicker(scheduleTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			b.fireScheduledRolls(ctx, now)
+			b.closeDueGroupRolls(ctx, now)
+		}
+	}
+}
+
+// fireScheduledRolls fires the scheduled rolls due by now.
+func (b *Bot) fireScheduledRolls(ctx context.Context, now time.Time) {
+	due, err := b.Cache.Redis.ZRangeByScoreWithScores(ctx, KeyScheduleDue, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.Unix(), 10),
+	}).Result()
+	if err != nil {
+		logger.Error("error getting scheduled rolls", zap.Error(err))
+		return
+	}
+	for _, z := range due {
+		id := z.Member.(string)
+		// lock this firing of the roll so other processes skip it
+		lock := fmt.Sprintf(KeyScheduleLockFmt, id, int64(z.Score))
+		if ok, err := b.Cache.Redis.SetNX(ctx, lock, b.SelfID, scheduleLockTTL).Result(); err != nil || !ok {
+			continue
+		}
+		b.fireScheduledRoll(ctx, id, now)
+	}
+}
+
+// fireScheduledRoll posts a scheduled roll to its channel and queues its next
+// time, or deletes it if it doesn't repeat.
+func (b *Bot) fireScheduledRoll(ctx context.Context, id string, now time.Time) {
+	defer metrics.IncrCounter([]string{"schedule", "fire"}, 1)
+	job, err := GetScheduledRoll(ctx, id)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			b.Cache.Redis.ZRem(ctx, KeyScheduleDue, id)
+		} else {
+			logger.Error("error getting scheduled roll", zap.String("id", id), zap.Error(err))
+		}
+		return
+	}
+
+	s := b.Sessions[0]
+	content, err := rollScheduledRoll(newScheduleContext(ctx, s, job), job)
+	if err != nil {
+		content = "⏰ " + createFriendlyError(err).Error()
+	}
+	_, err = s.ChannelMessageSendComplex(job.Channel, &discordgo.MessageSend{
+		Content: content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{
+			Users: []string{},
+		},
+	})
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil &&
+		(restErr.Response.StatusCode == http.StatusForbidden || restErr.Response.StatusCode == http.StatusNotFound) {
+		// the channel is gone or can't be posted to anymore
+		logger.Warn("deleting undeliverable scheduled roll", zap.String("id", id), zap.Error(err))
+		_, _ = DeleteScheduledRoll(ctx, job.Guild, job.ID)
+		return
+	} else if err != nil {
+		logger.Error("error sending scheduled roll", zap.String("id", id), zap.Error(err))
+	}
+
+	next := nextScheduleTime(time.Unix(job.Next, 0), job.Every, now)
+	if next.IsZero() {
+		if _, err := DeleteScheduledRoll(ctx, job.Guild, job.ID); err != nil {
+			logger.Error("error deleting scheduled roll", zap.String("id", id), zap.Error(err))
+		}
+		return
+	}
+	job.Next = next.Unix()
+	if err := SetScheduledRoll(ctx, job); err != nil {
+		logger.Error("error rescheduling roll", zap.String("id", id), zap.Error(err))
+	}
+}
+
+// InteractionSchedule schedules rolls to be posted to a server's channels.
+func InteractionSchedule(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "schedule"}, 1)
+
+	if i.Member == nil || i.Member.Permissions&discordgo.PermissionManageGuild == 0 {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("This command requires the _Manage Server_ permission."))
+		return
+	}
+	if DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	subcommand := options[0].Name
+	options = options[0].Options
+
+	switch subcommand {
+	case "roll":
+		now := time.Now()
+		job := &ScheduledRoll{
+			ID:         i.ID,
+			Owner:      UserFromInteraction(i).ID,
+			Guild:      i.GuildID,
+			Channel:    i.ChannelID,
+			Expression: strings.TrimSpace(mustGetOptionByName(options, "expression").StringValue()),
+		}
+		at, err := parseScheduleTime(mustGetOptionByName(options, "at").StringValue(), now)
+		if err != nil {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(err.Error()))
+			return
+		}
+		job.Next = at.Unix()
+		if opt := getOptionByName(options, "every"); opt != nil {
+			if job.Every, err = parseScheduleDuration(opt.StringValue()); err != nil || job.Every < minScheduleInterval {
+				MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidScheduleInterval.Error()))
+				return
+			}
+		}
+		if opt := getOptionByName(options, "channel"); opt != nil {
+			job.Channel = opt.ChannelValue(nil).ID
+		}
+		if opt := getOptionByName(options, "label"); opt != nil {
+			job.Label = opt.StringValue()
+		}
+
+		// make sure the roll works before it's left to fire unattended
+		if _, err := rollScheduledRoll(ctx, job); err != nil {
+			message := createFriendlyError(err).Error()
+			if errors.Is(err, ErrUnknownTable) || errors.Is(err, ErrTableDepth) || errors.Is(err, ErrTableInline) {
+				message = "Sorry! " + err.Error()
+			}
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(message))
+			return
+		}
+		if jobs, err := GetScheduledRolls(ctx, i.GuildID); err == nil && len(jobs) >= maxScheduledRolls {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrTooManyScheduledRolls.Error()))
+			return
+		}
+		if err := SetScheduledRoll(ctx, job); err != nil {
+			logger.Error("error scheduling roll", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+			fmt.Sprintf("Scheduled %s (<t:%d:F>).", describeScheduledRoll(job), job.Next)))
+	case "list":
+		jobs, err := GetScheduledRolls(ctx, i.GuildID)
+		if err != nil {
+			logger.Error("error getting scheduled rolls", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if len(jobs) == 0 {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+				fmt.Sprintf("This server has no scheduled rolls. Use %s to schedule one.", CommandMention("schedule", "roll"))))
+			return
+		}
+		lines := make([]string, len(jobs))
+		for j, job := range jobs {
+			lines[j] = "- " + describeScheduledRoll(job)
+		}
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(strings.Join(lines, "\n")))
+	case "cancel":
+		id := mustGetOptionByName(options, "roll").StringValue()
+		ok, err := DeleteScheduledRoll(ctx, i.GuildID, id)
+		if err != nil {
+			logger.Error("error deleting scheduled roll", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if !ok {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("That scheduled roll wasn't found."))
+			return
+		}
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Cancelled the scheduled roll."))
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry, an invalid subcommand was received!"))
+	}
+}
+
+// SuggestScheduledRolls suggests the scheduled rolls of the server.
+func SuggestScheduledRolls(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	if DiceGolem.Cache.Redis != nil && i.GuildID != "" {
+		jobs, _ := GetScheduledRolls(ctx, i.GuildID)
+		for _, job := range jobs {
+			name := job.Expression
+			if job.Label != "" {
+				name = job.Label + ": " + name
+			}
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  truncString(name, 100),
+				Value: job.ID,
+			})
+		}
+	}
+	if input := getOptionByName(i.ApplicationCommandData().Options, "roll").StringValue(); input != "" {
+		choices = fuzzyFilterOptionChoices(input, choices)
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, newChoicesResponse(trunc(choices, 25))); err != nil {
+		logger.Error("autocomplete", zap.Error(err))
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_parseScheduleDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "90m", want: 90 * time.Minute},
+		{input: "1d", want: 24 * time.Hour},
+		{input: " 2w ", want: 14 * 24 * time.Hour},
+		{input: "xd", wantErr: true},
+		{input: "daily", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseScheduleDuration(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseScheduleDuration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseScheduleDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseScheduleTime(t *testing.T) {
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "2h", want: now.Add(2 * time.Hour)},
+		{input: "1d", want: now.Add(24 * time.Hour)},
+		{input: "18:30", want: time.Date(2024, 1, 31, 18, 30, 0, 0, time.UTC)},
+		{input: "09:00", want: time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{input: "2024-02-14 18:30", want: time.Date(2024, 2, 14, 18, 30, 0, 0, time.UTC)},
+		{input: "<t:1706745600:F>", want: time.Unix(1706745600, 0)},
+		{input: "<t:1706745600>", want: time.Unix(1706745600, 0)},
+		{input: "2024-01-01", wantErr: true},
+		{input: "-1h", wantErr: true},
+		{input: "2026-01-01", wantErr: true},
+		{input: "tomorrow", wantErr: true},
+		{input: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseScheduleTime(tt.input, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseScheduleTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseScheduleTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_nextScheduleTime(t *testing.T) {
+	start := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		every time.Duration
+		now   time.Time
+		want  time.Time
+	}{
+		{name: "once", now: start, want: time.Time{}},
+		{name: "daily", every: 24 * time.Hour, now: start, want: start.Add(24 * time.Hour)},
+		{name: "missed runs", every: time.Hour, now: start.Add(150 * time.Minute), want: start.Add(3 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextScheduleTime(start, tt.every, tt.now); !got.Equal(tt.want) {
+				t.Errorf("nextScheduleTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
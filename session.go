@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/mitchellh/mapstructure"
+	"github.com/redis/go-redis/v9"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// RollEvent is a roll made within a channel, recorded to the channel's event
+// stream.
+type RollEvent struct {
+	ID         string  `mapstructure:"id" json:"id" csv:"id"`
+	User       string  `mapstructure:"user" json:"user" csv:"user"`
+	Input      string  `mapstructure:"input" json:"input" csv:"input"`
+	Expression string  `mapstructure:"expression" json:"expression" csv:"expression"`
+	Label      string  `mapstructure:"label,omitempty" json:"label,omitempty" csv:"label"`
+	Rolled     string  `mapstructure:"rolled" json:"rolled" csv:"rolled"`
+	Dice       string  `mapstructure:"dice" json:"dice" csv:"dice"`
+	Result     float64 `mapstructure:"result" json:"result" csv:"result"`
+
+	// Natural 20s, natural 1s, and number of d20s rolled
+	Crits   int `mapstructure:"crits" json:"crits" csv:"crits"`
+	Fumbles int `mapstructure:"fumbles" json:"fumbles" csv:"fumbles"`
+	D20s    int `mapstructure:"d20s" json:"-" csv:"-"`
+
+	// Summed luck score and number of polyhedral dice scored
+	Luck  float64 `mapstructure:"luck" json:"-" csv:"-"`
+	Count int     `mapstructure:"count" json:"-" csv:"-"`
+
+	// Time the roll was made, from the stream entry's ID.
+	Time time.Time `mapstructure:"-" json:"time" csv:"time"`
+}
+
+// NewRollEvent creates a roll event for a user from a roll response.
+func NewRollEvent(id, uid string, res *Response) *RollEvent {
+	event := &RollEvent{
+		ID:         id,
+		User:       uid,
+		Expression: res.Expression,
+		Label:      res.Label,
+		Rolled:     res.Rolled,
+	}
+	if res.ExpressionResult != nil {
+		event.Result = res.ExpressionResult.Result
+	}
+	groups := res.Groups()
+	event.Dice = plainDetails(res)
+	event.Luck, event.Count = diceLuck(groups)
+	eachDie(groups, func(d *dice.Die) {
+		if d.Type != dice.TypePolyhedron || d.Size != 20 || d.IsDropped(context.Background()) {
+			return
+		}
+		event.D20s++
+		switch d.Result.Value {
+		case 20:
+			event.Crits++
+		case 1:
+			event.Fumbles++
+		}
+	})
+	return event
+}
+
+// Values returns the event as stream entry values.
+func (e *RollEvent) Values() map[string]any {
+	values := make(map[string]any)
+	_ = mapstructure.Decode(e, &values)
+	return values
+}
+
+// rollEventFromMessage decodes a roll event from a stream entry.
+func rollEventFromMessage(msg redis.XMessage) (*RollEvent, error) {
+	event := new(RollEvent)
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           event,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(msg.Values); err != nil {
+		return nil, err
+	}
+	ms, _, _ := strings.Cut(msg.ID, "-")
+	if ts, err := strconv.ParseInt(ms, 10, 64); err == nil {
+		event.Time = time.UnixMilli(ts)
+	}
+	return event, nil
+}
+
+// RecordRollEventFromContext appends a public roll to its channel's event
+// stream. This should be called in a goroutine.
+func RecordRollEventFromContext(ctx context.Context, id string, input *NamedRollInput, res *Response) {
+	// if no Redis cache, skip
+	if DiceGolem.Cache.Redis == nil {
+		return
+	}
+
+	defer recover()
+	if !isRollPublic(ctx) {
+		return
+	}
+	uid, cid, _ := idsFromContext(ctx)
+	event := NewRollEvent(id, uid, res)
+	if input != nil {
+		event.Input = input.RollableString()
+	}
+
+	key := fmt.Sprintf(KeyChannelEventsFmt, cid)
+	defer metrics.MeasureSince([]string{"redis", "record_event"}, time.Now())
+	_, err := DiceGolem.Cache.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: key,
+			MaxLen: int64(DiceGolem.MaxEvents),
+			Approx: true,
+			Values: event.Values(),
+		})
+		pipe.Expire(ctx, key, DiceGolem.HistoryTTL)
+		return nil
+	})
+	if err != nil {
+		logger.Error("error recording roll event", zap.Error(err))
+	}
+}
+
+// rollEventsStart returns the stream ID to read roll events from for a time.
+// A zero time reads from the start of the stream.
+func rollEventsStart(since time.Time) string {
+	if since.IsZero() {
+		return "-"
+	}
+	return strconv.FormatInt(since.UnixMilli(), 10)
+}
+
+// GetRollEvents returns the roll events of a channel made since a time, from
+// earliest to latest.
+func GetRollEvents(ctx context.Context, cid string, since time.Time) ([]*RollEvent, error) {
+	if DiceGolem.Cache.Redis == nil {
+		return nil, ErrNoRedisClient
+	}
+	msgs, err := DiceGolem.Cache.Redis.XRange(ctx, fmt.Sprintf(KeyChannelEventsFmt, cid), rollEventsStart(since), "+").Result()
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*RollEvent, 0, len(msgs))
+	for _, msg := range msgs {
+		event, err := rollEventFromMessage(msg)
+		if err != nil {
+			logger.Warn("invalid roll event", zap.String("id", msg.ID), zap.Error(err))
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// SessionSummary is the aggregate of roll events made during a session.
+type SessionSummary struct {
+	Total   int
+	Rolls   map[string]int
+	Crits   map[string]int
+	Fumbles map[string]int
+
+	// Highest result of a roll without any d20s, ex. a damage roll.
+	Highest *RollEvent
+
+	// Player with the highest average luck and their score.
+	Luckiest string
+	Luck     float64
+}
+
+// summarizeSession aggregates a session's roll events.
+func summarizeSession(events []*RollEvent) *SessionSummary {
+	summary := &SessionSummary{
+		Total:   len(events),
+		Rolls:   make(map[string]int),
+		Crits:   make(map[string]int),
+		Fumbles: make(map[string]int),
+	}
+	luck := make(map[string]float64)
+	count := make(map[string]int)
+	for _, event := range events {
+		summary.Rolls[event.User]++
+		if event.Crits > 0 {
+			summary.Crits[event.User] += event.Crits
+		}
+		if event.Fumbles > 0 {
+			summary.Fumbles[event.User] += event.Fumbles
+		}
+		if event.D20s == 0 && (summary.Highest == nil || event.Result > summary.Highest.Result) {
+			summary.Highest = event
+		}
+		luck[event.User] += event.Luck
+		count[event.User] += event.Count
+	}
+	for _, user := range sortedKeys(count) {
+		if count[user] == 0 {
+			continue
+		}
+		if avg := luck[user] / float64(count[user]); summary.Luckiest == "" || avg > summary.Luck {
+			summary.Luckiest = user
+			summary.Luck = avg
+		}
+	}
+	return summary
+}
+
+// sortedKeys returns the keys of a map sorted by descending value, then by key.
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if m[a] != m[b] {
+			return m[b] - m[a]
+		}
+		return strings.Compare(a, b)
+	})
+	return keys
+}
+
+// markdownUserCounts renders per-user counts as a list of user mentions.
+func markdownUserCounts(m map[string]int) string {
+	if len(m) == 0 {
+		return "None"
+	}
+	var b strings.Builder
+	for _, user := range sortedKeys(m) {
+		fmt.Fprintf(&b, "<@%s>: %d\n", user, m[user])
+	}
+	return truncString(b.String(), 1024)
+}
+
+func makeEmbedSessionSummary(summary *SessionSummary, start, end time.Time) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       "Session Summary",
+		Description: fmt.Sprintf("%d rolls made from <t:%d:f> to <t:%d:f>.", summary.Total, start.Unix(), end.Unix()),
+		Footer:      makeEmbedFooter(),
+	}
+	if summary.Total == 0 {
+		return embed
+	}
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{
+			Name:  "Rolls",
+			Value: markdownUserCounts(summary.Rolls),
+		},
+		{
+			Name:   "Crits",
+			Value:  markdownUserCounts(summary.Crits),
+			Inline: true,
+		},
+		{
+			Name:   "Fumbles",
+			Value:  markdownUserCounts(summary.Fumbles),
+			Inline: true,
+		},
+	}
+	if summary.Highest != nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "Highest Damage",
+			Value: fmt.Sprintf("<@%s> `%s`: `%s` = **%s**", summary.Highest.User,
+				summary.Highest.Expression, summary.Highest.Rolled,
+				strconv.FormatFloat(summary.Highest.Result, 'f', -1, 64)),
+		})
+	}
+	if summary.Luckiest != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Luckiest Player",
+			Value: fmt.Sprintf("<@%s> (%+.1f)", summary.Luckiest, summary.Luck),
+		})
+	}
+	return embed
+}
+
+// InteractionSession starts and ends roll sessions within a channel.
+func InteractionSession(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "session"}, 1)
+
+	if DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	key := fmt.Sprintf(KeyChannelSessionFmt, i.ChannelID)
+	options := i.ApplicationCommandData().Options
+	switch options[0].Name {
+	case "start":
+		now := time.Now()
+		if err := DiceGolem.Cache.Redis.Set(ctx, key, now.UnixMilli(), DiceGolem.HistoryTTL).Err(); err != nil {
+			logger.Error("error starting session", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("Session started <t:%d:R>! Use %s to end it and post a summary of the rolls made.",
+					now.Unix(), CommandMention("session", "end")),
+			},
+		}); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+	case "end":
+		ms, err := DiceGolem.Cache.Redis.GetDel(ctx, key).Int64()
+		if err != nil {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+				fmt.Sprintf("There is no session in progress in this channel. Use %s to start one.", CommandMention("session", "start"))))
+			return
+		}
+		start, end := time.UnixMilli(ms), time.Now()
+		events, err := GetRollEvents(ctx, i.ChannelID, start)
+		if err != nil {
+			logger.Error("error getting roll events", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if err := MeasureInteractionRespond(s.InteractionRespond, i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds: []*discordgo.MessageEmbed{
+					makeEmbedSessionSummary(summarizeSession(events), start, end),
+				},
+				AllowedMentions: &discordgo.MessageAllowedMentions{
+					Users: []string{},
+				},
+			},
+		}); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry, an invalid subcommand was received!"))
+	}
+}
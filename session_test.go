@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRollEvent_Values(t *testing.T) {
+	event := &RollEvent{ID: "2", User: "1", Input: "1d20+2 # init", Expression: "1d20+2", Rolled: "[20]+2", Dice: "[20]", Result: 22, Crits: 1, D20s: 1, Luck: 100, Count: 1}
+	values := event.Values()
+	if _, ok := values["Time"]; ok {
+		t.Errorf("RollEvent.Values() included Time")
+	}
+
+	// round trip through stream string values
+	strs := make(map[string]any, len(values))
+	for k, v := range values {
+		strs[k] = fmt.Sprint(v)
+	}
+	got, err := rollEventFromMessage(redis.XMessage{ID: "1700000000000-0", Values: strs})
+	if err != nil {
+		t.Fatalf("rollEventFromMessage() error = %v", err)
+	}
+	if got.Time.UnixMilli() != 1700000000000 {
+		t.Errorf("rollEventFromMessage() time = %v", got.Time)
+	}
+	got.Time = event.Time
+	if !reflect.DeepEqual(got, event) {
+		t.Errorf("rollEventFromMessage() = %+v, want %+v", got, event)
+	}
+}
+
+func Test_summarizeSession(t *testing.T) {
+	events := []*RollEvent{
+		{User: "a", Expression: "1d20", Result: 20, Crits: 1, D20s: 1, Luck: 100, Count: 1},
+		{User: "a", Expression: "2d6", Result: 4, Luck: -120, Count: 2},
+		{User: "b", Expression: "1d20", Result: 1, Fumbles: 1, D20s: 1, Luck: -100, Count: 1},
+		{User: "b", Expression: "8d6", Result: 30, Luck: 80, Count: 8},
+	}
+	got := summarizeSession(events)
+	if got.Total != 4 {
+		t.Errorf("Total = %d, want 4", got.Total)
+	}
+	if !reflect.DeepEqual(got.Rolls, map[string]int{"a": 2, "b": 2}) {
+		t.Errorf("Rolls = %v", got.Rolls)
+	}
+	if !reflect.DeepEqual(got.Crits, map[string]int{"a": 1}) || !reflect.DeepEqual(got.Fumbles, map[string]int{"b": 1}) {
+		t.Errorf("Crits = %v, Fumbles = %v", got.Crits, got.Fumbles)
+	}
+	if got.Highest != events[3] {
+		t.Errorf("Highest = %v, want %v", got.Highest, events[3])
+	}
+	if got.Luckiest != "b" {
+		t.Errorf("Luckiest = %v, want b", got.Luckiest)
+	}
+}
+
+func Test_rollEventsStart(t *testing.T) {
+	tests := []struct {
+		name  string
+		since time.Time
+		want  string
+	}{
+		{name: "zero", since: time.Time{}, want: "-"},
+		{name: "time", since: time.UnixMilli(1700000000123), want: "1700000000123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rollEventsStart(tt.since); got != tt.want {
+				t.Errorf("rollEventsStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
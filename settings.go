@@ -21,6 +21,7 @@ const (
 	KeyUserGuildPreferencesFmt   = KeyUserPreferencesFmt + ":guild:%s" // User guild preferences
 	KeyUserChannelPreferencesFmt = KeyUserPreferencesFmt + ":chan:%s"  // User DM/GDM preferences
 	KeyGuildSettingsFmt          = "settings:guild:%s"                 // Guild global settings
+	KeyGuildValuesFmt            = KeyGuildSettingsFmt + ":values"     // Guild global setting values
 	KeyChannelSettingsFmt        = KeyGuildSettingsFmt + ":chan:%s"    // Guild channel settings (overrides)
 	KeyChannelNamedSettingFmt    = KeyChannelSettingsFmt + ":%s"
 )
@@ -32,6 +33,9 @@ type SettingName string
 const (
 	SettingNoRecent       SettingName = "norecent"
 	SettingDetailed       SettingName = "detailed"
+	SettingImage          SettingName = "image"
+	SettingCards          SettingName = "cards"
+	SettingNoCards        SettingName = "nocards"
 	SettingNoAutocomplete SettingName = "noautocomplete"
 	SettingSilent         SettingName = "silent"
 
@@ -44,12 +48,16 @@ func (s SettingName) String() string {
 
 func UserSetPreference(u *discordgo.User, s SettingName) {
 	ctx := context.TODO()
-	DiceGolem.Cache.Redis.SAdd(ctx, fmt.Sprintf(KeyUserPreferencesFmt, u.ID), s.String())
+	key := fmt.Sprintf(KeyUserPreferencesFmt, u.ID)
+	defer DiceGolem.Cache.Remove(key)
+	DiceGolem.Cache.Redis.SAdd(ctx, key, s.String())
 }
 
 func UserUnsetPreference(u *discordgo.User, s SettingName) {
 	ctx := context.TODO()
-	DiceGolem.Cache.Redis.SRem(ctx, fmt.Sprintf(KeyUserPreferencesFmt, u.ID), s.String())
+	key := fmt.Sprintf(KeyUserPreferencesFmt, u.ID)
+	defer DiceGolem.Cache.Remove(key)
+	DiceGolem.Cache.Redis.SRem(ctx, key, s.String())
 }
 
 func UserHasPreference(u *discordgo.User, s SettingName) bool {
@@ -65,6 +73,7 @@ func GuildHasSetting(g *discordgo.Guild, s SettingName) bool {
 func GuildSetSetting(g *discordgo.Guild, s SettingName) {
 	ctx := context.TODO()
 	key := fmt.Sprintf(KeyGuildSettingsFmt, g.ID)
+	defer DiceGolem.Cache.Remove(key)
 	DiceGolem.Cache.Redis.SAdd(ctx, key, s.String())
 	defer DiceGolem.Cache.Redis.Expire(ctx, key, DiceGolem.DataTTL)
 }
@@ -72,6 +81,7 @@ func GuildSetSetting(g *discordgo.Guild, s SettingName) {
 func GuildUnsetSetting(g *discordgo.Guild, s SettingName) {
 	ctx := context.TODO()
 	key := fmt.Sprintf(KeyGuildSettingsFmt, g.ID)
+	defer DiceGolem.Cache.Remove(key)
 	DiceGolem.Cache.Redis.SRem(ctx, key, s.String())
 }
 
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gocarina/gocsv"
+	"github.com/redis/go-redis/v9"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// Limits of random tables.
+const (
+	maxTables        = 25
+	maxTableEntries  = 200
+	maxTableDepth    = 5
+	maxTableInline   = 100
+	maxTableRolls    = 10
+	maxTableFileSize = 64 * 1024
+)
+
+var tableNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+// tableInlineRegexp matches inline rolls of table entries, either dice
+// expressions like "[[2d6]]" or references to other tables like
+// "[[table:treasure]]".
+var tableInlineRegexp = regexp.MustCompile(`\[\[([^\[\]]+)\]\]`)
+
+// Errors for random tables.
+var (
+	ErrInvalidTableName  = errors.New("Table names must start with a letter and only contain letters, numbers, `-` and `_`.")
+	ErrInvalidTable      = errors.New("Tables need a `result` column and either a `weight` or a `range` column for every entry.")
+	ErrTooManyTables     = fmt.Errorf("You can only create up to %d tables. Delete some before creating more.", maxTables)
+	ErrTooManyEntries    = fmt.Errorf("Tables can only have up to %d entries.", maxTableEntries)
+	ErrTableDepth        = fmt.Errorf("Tables can only reference other tables %d levels deep.", maxTableDepth)
+	ErrTableInline       = fmt.Errorf("Table results can only make up to %d inline rolls.", maxTableInline)
+	ErrUnknownTable      = errors.New("unknown table")
+	ErrOverlappingRanges = errors.New("The ranges of a table's entries can't overlap.")
+)
+
+// A TableEntry is an entry of a random table. Entries are read from CSV with
+// either a weight or a range of die results, and are stored with their range.
+type TableEntry struct {
+	Weight int    `csv:"weight,omitempty" json:"-"`
+	Range  string `csv:"range,omitempty" json:"-"`
+	Result string `csv:"result" json:"result"`
+	Low    int    `csv:"-" json:"low"`
+	High   int    `csv:"-" json:"high"`
+}
+
+// A Table is a random table rolled on with a die of its size.
+type Table struct {
+	Name    string        `json:"name"`
+	Size    int           `json:"size"`
+	Entries []*TableEntry `json:"entries"`
+}
+
+// parseTableRange parses a range of die results like "4" or "1-3".
+func parseTableRange(s string) (low, high int, err error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "–", "-")
+	lo, hi, ok := strings.Cut(s, "-")
+	if low, err = strconv.Atoi(strings.TrimSpace(lo)); err != nil {
+		return 0, 0, ErrInvalidTable
+	}
+	high = low
+	if ok {
+		if high, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil {
+			return 0, 0, ErrInvalidTable
+		}
+	}
+	if low < 1 || high < low {
+		return 0, 0, ErrInvalidTable
+	}
+	return low, high, nil
+}
+
+// ParseTable parses a table out of CSV data with weight, range and result
+// columns. Entries with weights are given consecutive ranges.
+func ParseTable(name string, data []byte) (*Table, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !tableNameRegexp.MatchString(name) {
+		return nil, ErrInvalidTableName
+	}
+	var entries []*TableEntry
+	if err := gocsv.UnmarshalBytes(data, &entries); err != nil {
+		return nil, fmt.Errorf("%w (%v)", ErrInvalidTable, err)
+	}
+	if len(entries) == 0 {
+		return nil, ErrInvalidTable
+	}
+	if len(entries) > maxTableEntries {
+		return nil, ErrTooManyEntries
+	}
+
+	table := &Table{Name: name, Entries: entries}
+	for _, entry := range entries {
+		entry.Result = strings.TrimSpace(entry.Result)
+		if entry.Result == "" {
+			return nil, ErrInvalidTable
+		}
+		switch {
+		case entry.Range != "":
+			low, high, err := parseTableRange(entry.Range)
+			if err != nil {
+				return nil, err
+			}
+			entry.Low, entry.High = low, high
+		case entry.Weight > 0:
+			entry.Low, entry.High = table.Size+1, table.Size+entry.Weight
+		default:
+			return nil, ErrInvalidTable
+		}
+		table.Size = max(table.Size, entry.High)
+	}
+
+	sorted := slices.Clone(entries)
+	slices.SortFunc(sorted, func(a, b *TableEntry) int { return a.Low - b.Low })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Low <= sorted[i-1].High {
+			return nil, ErrOverlappingRanges
+		}
+	}
+	return table, nil
+}
+
+// Entry returns the entry of the table for a die result, if any.
+func (t *Table) Entry(result int) *TableEntry {
+	for _, entry := range t.Entries {
+		if result >= entry.Low && result <= entry.High {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Roll rolls on the table, returning the die result and the entry rolled.
+// Results in gaps between ranges have no entry.
+func (t *Table) Roll() (int, *TableEntry) {
+	result := dice.Source.Intn(t.Size) + 1
+	return result, t.Entry(result)
+}
+
+// A TableLookup finds a table by name.
+type TableLookup func(name string) (*Table, bool)
+
+// expandTableResult rolls the inline rolls of a table entry's result. Dice
+// expressions are replaced with their results and table references with a
+// roll on the table, up to maxTableDepth tables deep and maxTableInline inline
+// rolls in total.
+func expandTableResult(ctx context.Context, result string, lookup TableLookup, depth int) (string, error) {
+	budget := maxTableInline
+	return expandTableInline(ctx, result, lookup, depth, &budget)
+}
+
+// expandTableInline expands the inline rolls of a table entry's result for
+// expandTableResult, spending the budget of inline rolls shared by every
+// table referenced.
+func expandTableInline(ctx context.Context, result string, lookup TableLookup, depth int, budget *int) (string, error) {
+	var errs []error
+	expanded := tableInlineRegexp.ReplaceAllStringFunc(result, func(match string) string {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			return match
+		}
+		// report running out of inline rolls only once
+		if *budget <= 0 {
+			if *budget == 0 {
+				errs = append(errs, ErrTableInline)
+				*budget--
+			}
+			return match
+		}
+		*budget--
+		inner := strings.TrimSpace(tableInlineRegexp.FindStringSubmatch(match)[1])
+		if name, ok := strings.CutPrefix(inner, "table:"); ok {
+			if depth >= maxTableDepth {
+				errs = append(errs, ErrTableDepth)
+				return match
+			}
+			table, ok := lookup(strings.ToLower(strings.TrimSpace(name)))
+			if !ok {
+				errs = append(errs, fmt.Errorf("%w: %q", ErrUnknownTable, name))
+				return match
+			}
+			_, entry := table.Roll()
+			if entry == nil {
+				return ""
+			}
+			s, err := expandTableInline(ctx, entry.Result, lookup, depth+1, budget)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			return s
+		}
+		res, _, err := evaluateRoll(ctx, inner)
+		if err != nil {
+			errs = append(errs, err)
+			return match
+		}
+		return strconv.FormatFloat(res.Result, 'f', -1, 64)
+	})
+	return expanded, errors.Join(errs...)
+}
+
+// tablesKey returns the storage key of the tables created by a user, or for a
+// guild if gid is set.
+func tablesKey(uid, gid string) string {
+	if gid != "" {
+		return fmt.Sprintf(KeyCacheGuildTablesFmt, gid)
+	}
+	return fmt.Sprintf(KeyCacheUserTablesFmt, uid)
+}
+
+// GetTables returns the tables created by a user, or for a guild if gid is
+// set, by name.
+func GetTables(ctx context.Context, uid, gid string) map[string]*Table {
+	tables := make(map[string]*Table)
+	for name, data := range DiceGolem.Cache.HGetAll(ctx, tablesKey(uid, gid)) {
+		table := new(Table)
+		if err := json.Unmarshal([]byte(data), table); err == nil {
+			tables[name] = table
+		}
+	}
+	return tables
+}
+
+// tableLookup finds tables created by a user, falling back to the tables
+// created for the guild.
+func tableLookup(ctx context.Context, uid, gid string) TableLookup {
+	return func(name string) (*Table, bool) {
+		if table, ok := GetTables(ctx, uid, "")[name]; ok {
+			return table, true
+		}
+		if gid == "" {
+			return nil, false
+		}
+		table, ok := GetTables(ctx, uid, gid)[name]
+		return table, ok
+	}
+}
+
+// SetTable saves a table for a user, or for a guild if gid is set.
+func SetTable(ctx context.Context, uid, gid string, table *Table) error {
+	if DiceGolem.Cache.Redis == nil {
+		return ErrNoRedisClient
+	}
+	key := tablesKey(uid, gid)
+	tables := GetTables(ctx, uid, gid)
+	if _, ok := tables[table.Name]; !ok && len(tables) >= maxTables {
+		return ErrTooManyTables
+	}
+	data, err := json.Marshal(table)
+	if err != nil {
+		return err
+	}
+	_, err = DiceGolem.Cache.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		defer DiceGolem.Cache.Remove(key)
+		pipe.HSet(ctx, key, table.Name, string(data))
+		pipe.Expire(ctx, key, DiceGolem.DataTTL)
+		return nil
+	})
+	return err
+}
+
+// DeleteTable deletes a table for a user, or for a guild if gid is set,
+// returning whether it existed.
+func DeleteTable(ctx context.Context, uid, gid, name string) (bool, error) {
+	if DiceGolem.Cache.Redis == nil {
+		return false, ErrNoRedisClient
+	}
+	key := tablesKey(uid, gid)
+	defer DiceGolem.Cache.Remove(key)
+	n, err := DiceGolem.Cache.Redis.HDel(ctx, key, name).Result()
+	return n > 0, err
+}
+
+// markdownTables lists tables with their dice and number of entries.
+func markdownTables(tables map[string]*Table) string {
+	if len(tables) == 0 {
+		return "None"
+	}
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "`%s` (d%d, %s)\n", name, tables[name].Size,
+			pluralize(len(tables[name].Entries), "entry", "entries"))
+	}
+	return truncString(b.String(), 1024)
+}
+
+// fetchAttachment downloads a command's attachment, up to a maximum size.
+func fetchAttachment(ctx context.Context, attachment *discordgo.MessageAttachment, limit int) ([]byte, error) {
+	if attachment.Size > limit {
+		return nil, fmt.Errorf("attachment is larger than %d bytes", limit)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, attachment.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("attachment download failed: %s", res.Status)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, int64(limit)))
+}
+
+// makeCreateTableModal creates a modal to enter a table's CSV data.
+func makeCreateTableModal(name, gid string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: newComponentID("modal_table", name, gid),
+			Title:    truncString("Create Table: "+name, 45),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "csv",
+							Label:       "Table entries (CSV format)",
+							Style:       discordgo.TextInputParagraph,
+							Placeholder: "weight,result\n3,[[2d6]] goblins\n1,A wandering merchant\n1,[[table:treasure]]\n",
+							Required:    true,
+							MaxLength:   4000,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// saveTable parses and saves a table, responding with the outcome.
+func saveTable(ctx context.Context, name, gid string, data []byte) error {
+	s, i, _ := FromContext(ctx)
+	table, err := ParseTable(name, data)
+	if err != nil {
+		return MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(err.Error()))
+	}
+	if err := SetTable(ctx, UserFromInteraction(i).ID, gid, table); err != nil {
+		if errors.Is(err, ErrTooManyTables) {
+			return MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(err.Error()))
+		}
+		logger.Error("error saving table", zap.Error(err))
+		return MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+	}
+	return MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+		fmt.Sprintf("Saved table `%s` (d%d, %s)! Roll on it with %s.",
+			table.Name, table.Size, pluralize(len(table.Entries), "entry", "entries"), CommandMention("table", "roll"))))
+}
+
+// CreateTableModalInteraction saves a table submitted with the create table
+// modal.
+func CreateTableModalInteraction(ctx context.Context, data map[string]any) error {
+	s, i, _ := FromContext(ctx)
+	_, args := parseComponentID(i.ModalSubmitData().CustomID)
+	csv, _ := data["csv"].(string)
+	if len(args) != 2 || csv == "" {
+		return MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Table data was empty! No changes will be made."))
+	}
+	// the modal's custom ID isn't trusted to grant access to a server's tables
+	gid := args[1]
+	if gid != "" && (gid != i.GuildID || i.Member == nil || i.Member.Permissions&discordgo.PermissionManageGuild == 0) {
+		return MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Managing tables for the server requires the _Manage Server_ permission."))
+	}
+	return saveTable(ctx, args[0], gid, []byte(csv))
+}
+
+// InteractionTable manages and rolls on random tables.
+func InteractionTable(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "table"}, 1)
+
+	if DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	uid := UserFromInteraction(i).ID
+	data := i.ApplicationCommandData()
+	subcommand := data.Options[0].Name
+	options := data.Options[0].Options
+
+	// tables can be created for the whole server by its managers
+	var gid string
+	if opt := getOptionByName(options, "server"); opt != nil && opt.BoolValue() {
+		if i.GuildID == "" || i.Member == nil || i.Member.Permissions&discordgo.PermissionManageGuild == 0 {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Managing tables for the server requires the _Manage Server_ permission."))
+			return
+		}
+		gid = i.GuildID
+	}
+
+	switch subcommand {
+	case "create":
+		name := strings.ToLower(mustGetOptionByName(options, "name").StringValue())
+		if !tableNameRegexp.MatchString(name) {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrInvalidTableName.Error()))
+			return
+		}
+		opt := getOptionByName(options, "file")
+		if opt == nil {
+			if err := MeasureInteractionRespond(s.InteractionRespond, i, makeCreateTableModal(name, gid)); err != nil {
+				logger.Error("error sending modal", zap.Error(err))
+			}
+			return
+		}
+		attachment, ok := data.Resolved.Attachments[opt.Value.(string)]
+		if !ok {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		csv, err := fetchAttachment(ctx, attachment, maxTableFileSize)
+		if err != nil {
+			logger.Warn("error fetching table", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry! That file couldn't be read: "+err.Error()))
+			return
+		}
+		if err := saveTable(ctx, name, gid, csv); err != nil {
+			logger.Error("error sending response", zap.Error(err))
+		}
+	case "roll":
+		rollTableInteraction(ctx, strings.ToLower(mustGetOptionByName(options, "name").StringValue()))
+	case "delete":
+		name := strings.ToLower(mustGetOptionByName(options, "name").StringValue())
+		ok, err := DeleteTable(ctx, uid, gid, name)
+		var content string
+		switch {
+		case err != nil:
+			logger.Error("error deleting table", zap.Error(err))
+			content = ErrUnexpectedError.Error()
+		case !ok:
+			content = fmt.Sprintf("No table named `%s` was found.", name)
+		default:
+			content = fmt.Sprintf("Deleted table `%s`.", name)
+		}
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(content))
+	case "list":
+		content := "**Your tables**\n" + markdownTables(GetTables(ctx, uid, ""))
+		if i.GuildID != "" {
+			content += "\n**Server tables**\n" + markdownTables(GetTables(ctx, uid, i.GuildID))
+		}
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(content))
+	default:
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Sorry, an invalid subcommand was received!"))
+	}
+}
+
+// rollTableInteraction rolls on a table one or more times.
+func rollTableInteraction(ctx context.Context, name string) {
+	s, i, _ := FromContext(ctx)
+	options := i.ApplicationCommandData().Options
+	uid, _, gid := idsFromContext(ctx)
+	lookup := tableLookup(ctx, uid, gid)
+
+	table, ok := lookup(name)
+	if !ok {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(
+			fmt.Sprintf("No table named `%s` was found. Create one with %s!", name, CommandMention("table", "create"))))
+		return
+	}
+	times := 1
+	if opt := getOptionByName(options, "times"); opt != nil {
+		times = min(max(int(opt.IntValue()), 1), maxTableRolls)
+	}
+
+	var b strings.Builder
+	b.WriteString(ResponsePrefix)
+	if i.Member != nil && isInteractionPublic(i) {
+		b.WriteString(UserFromInteraction(i).Mention() + " rolled")
+	}
+	fmt.Fprintf(&b, " on `%s` (d%d):", table.Name, table.Size)
+	for range times {
+		result, entry := table.Roll()
+		text := "_Nothing_"
+		if entry != nil {
+			expanded, err := expandTableResult(ctx, entry.Result, lookup, 1)
+			if err != nil {
+				logger.Warn("error expanding table entry", zap.String("table", table.Name), zap.Error(err))
+			}
+			text = expanded
+		}
+		fmt.Fprintf(&b, "\n`%d` %s", result, text)
+	}
+
+	response := &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: truncString(b.String(), 2000),
+			AllowedMentions: &discordgo.MessageAllowedMentions{
+				Users: []string{},
+			},
+		},
+	}
+	if opt := getOptionByName(options, "secret"); opt != nil && opt.BoolValue() {
+		response.Data.Flags = discordgo.MessageFlagsEphemeral
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, response); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
+
+// SuggestTables suggests the names of a user's and guild's tables.
+func SuggestTables(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	uid := UserFromInteraction(i).ID
+	input := getOptionByName(i.ApplicationCommandData().Options, "name").StringValue()
+
+	var names []string
+	for name := range GetTables(ctx, uid, "") {
+		names = append(names, name)
+	}
+	if i.GuildID != "" {
+		for name := range GetTables(ctx, uid, i.GuildID) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	choices := fuzzyFilterOptionChoices(input, ChoicesFromStrings(names))
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, newChoicesResponse(trunc(DistinctChoices(choices), 25))); err != nil {
+		logger.Error("autocomplete", zap.Error(err), zap.String("user", uid))
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_parseTableRange(t *testing.T) {
+	tests := []struct {
+		s         string
+		low, high int
+		wantErr   bool
+	}{
+		{s: "4", low: 4, high: 4},
+		{s: "1-3", low: 1, high: 3},
+		{s: " 10 – 12 ", low: 10, high: 12},
+		{s: "3-1", wantErr: true},
+		{s: "0", wantErr: true},
+		{s: "a-b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			low, high, err := parseTableRange(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTableRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if low != tt.low || high != tt.high {
+				t.Errorf("parseTableRange() = %d, %d, want %d, %d", low, high, tt.low, tt.high)
+			}
+		})
+	}
+}
+
+func TestParseTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		wantSize int
+		wantErr  error
+	}{
+		{name: "weights", csv: "weight,result\n3,Goblins\n1,Merchant\n", wantSize: 4},
+		{name: "ranges", csv: "range,result\n1-3,Goblins\n4-6,Merchant\n", wantSize: 6},
+		{name: "gaps", csv: "range,result\n1,Goblins\n20,Dragon\n", wantSize: 20},
+		{name: "mixed", csv: "weight,range,result\n2,,Goblins\n,3-4,Merchant\n", wantSize: 4},
+		{name: "overlap", csv: "range,result\n1-3,Goblins\n3-6,Merchant\n", wantErr: ErrOverlappingRanges},
+		{name: "no weight", csv: "weight,result\n,Goblins\n", wantErr: ErrInvalidTable},
+		{name: "no result", csv: "weight,result\n1,\n", wantErr: ErrInvalidTable},
+		{name: "empty", csv: "weight,result\n", wantErr: ErrInvalidTable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := ParseTable("encounters", []byte(tt.csv))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseTable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && table.Size != tt.wantSize {
+				t.Errorf("ParseTable() size = %v, want %v", table.Size, tt.wantSize)
+			}
+		})
+	}
+	if _, err := ParseTable("2 tables", []byte("weight,result\n1,a\n")); !errors.Is(err, ErrInvalidTableName) {
+		t.Errorf("ParseTable() error = %v, wantErr %v", err, ErrInvalidTableName)
+	}
+}
+
+func TestTable_Entry(t *testing.T) {
+	table, err := ParseTable("encounters", []byte("range,result\n1-3,Goblins\n5,Dragon\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for result, want := range map[int]string{1: "Goblins", 3: "Goblins", 4: "", 5: "Dragon"} {
+		var got string
+		if entry := table.Entry(result); entry != nil {
+			got = entry.Result
+		}
+		if got != want {
+			t.Errorf("Entry(%d) = %q, want %q", result, got, want)
+		}
+	}
+}
+
+func Test_expandTableResult(t *testing.T) {
+	tables := map[string]*Table{
+		"loot": {Name: "loot", Size: 1, Entries: []*TableEntry{{Result: "[[1d1+4]] gold", Low: 1, High: 1}}},
+		"loop": {Name: "loop", Size: 1, Entries: []*TableEntry{{Result: "[[table:loop]]", Low: 1, High: 1}}},
+	}
+	lookup := func(name string) (*Table, bool) {
+		table, ok := tables[name]
+		return table, ok
+	}
+	tests := []struct {
+		name    string
+		result  string
+		want    string
+		wantErr error
+	}{
+		{name: "plain", result: "A wandering merchant", want: "A wandering merchant"},
+		{name: "dice", result: "[[2d1]] goblins", want: "2 goblins"},
+		{name: "nested", result: "A chest with [[table:loot]]", want: "A chest with 5 gold"},
+		{name: "unknown", result: "[[table:nope]]", want: "[[table:nope]]", wantErr: ErrUnknownTable},
+		{name: "too deep", result: "[[table:loop]]", want: "[[table:loop]]", wantErr: ErrTableDepth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandTableResult(context.Background(), tt.result, lookup, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expandTableResult() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expandTableResult() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_expandTableResult_limits(t *testing.T) {
+	fanout := &Table{Name: "fanout", Size: 1, Entries: []*TableEntry{{Result: strings.Repeat("[[table:fanout]]", 20), Low: 1, High: 1}}}
+	lookup := func(name string) (*Table, bool) {
+		return fanout, name == "fanout"
+	}
+	got, err := expandTableResult(context.Background(), "[[table:fanout]]", lookup, 1)
+	if !errors.Is(err, ErrTableInline) || strings.Count(err.Error(), ErrTableInline.Error()) != 1 {
+		t.Errorf("expandTableResult() error = %v, wantErr %v once", err, ErrTableInline)
+	}
+	if n := strings.Count(got, "[[table:fanout]]"); n > maxTableInline*20 {
+		t.Errorf("expandTableResult() left %d references, want at most %d", n, maxTableInline*20)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := expandTableResult(ctx, "[[table:fanout]]", lookup, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("expandTableResult() with a canceled context error = %v, want %v", err, context.Canceled)
+	}
+}
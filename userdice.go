@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/armon/go-metrics"
+	"github.com/bwmarrin/discordgo"
+	"github.com/redis/go-redis/v9"
+	"github.com/travis-g/dice"
+	"go.uber.org/zap"
+)
+
+// Limits of user-defined dice.
+const (
+	maxCustomDieFaces   = 100
+	maxCustomFaceLength = 32
+	maxCustomDice       = 25
+)
+
+var (
+	customDieNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+	customDiceRegexp    = regexp.MustCompile(`(?i)\b(\d*)d\{([a-z][a-z0-9_-]*)\}`)
+)
+
+// Errors for user-defined dice.
+var (
+	ErrInvalidDieName  = errors.New("Die names must start with a letter and only contain letters, numbers, `-` and `_`.")
+	ErrInvalidDieFaces = fmt.Errorf("Faces must be a comma-separated list of up to %d numbers or words, like `1,1,2,3,4,6` or `Hit,Miss,Crit`.", maxCustomDieFaces)
+	ErrTooManyDieTypes = fmt.Errorf("You can only define up to %d dice. Delete some before defining more.", maxCustomDice)
+)
+
+// ParseCustomDie parses a user-defined die out of a name and a comma-separated
+// list of faces. Numeric faces have values and other faces show themselves as
+// symbols.
+func ParseCustomDie(name, faces string) (*CustomDie, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !customDieNameRegexp.MatchString(name) {
+		return nil, ErrInvalidDieName
+	}
+	die := &CustomDie{Name: name}
+	for _, face := range strings.Split(faces, ",") {
+		face = strings.TrimSpace(face)
+		if face == "" || len(face) > maxCustomFaceLength {
+			return nil, ErrInvalidDieFaces
+		}
+		if v, err := strconv.ParseFloat(face, 64); err == nil {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, ErrInvalidDieFaces
+			}
+			die.Faces = append(die.Faces, Face{Value: v})
+		} else {
+			die.Faces = append(die.Faces, Face{Symbols: []string{face}})
+		}
+	}
+	if len(die.Faces) < 1 || len(die.Faces) > maxCustomDieFaces {
+		return nil, ErrInvalidDieFaces
+	}
+	return die, nil
+}
+
+// faceString renders a face as its value and symbols.
+func faceString(face Face) string {
+	parts := slices.Clone(face.Symbols)
+	if face.Value != 0 || len(parts) == 0 {
+		parts = append([]string{strconv.FormatFloat(face.Value, 'f', -1, 64)}, parts...)
+	}
+	return strings.Join(parts, " ")
+}
+
+// customDiceKey returns the storage key of the dice defined for a user, or for
+// a guild if gid is set.
+func customDiceKey(uid, gid string) string {
+	if gid != "" {
+		return fmt.Sprintf(KeyCacheGuildDiceFmt, gid)
+	}
+	return fmt.Sprintf(KeyCacheUserDiceFmt, uid)
+}
+
+// GetCustomDice returns the dice defined for a user, or for a guild if gid is
+// set, by name.
+func GetCustomDice(ctx context.Context, uid, gid string) map[string]*CustomDie {
+	defined := make(map[string]*CustomDie)
+	for name, data := range DiceGolem.Cache.HGetAll(ctx, customDiceKey(uid, gid)) {
+		die := new(CustomDie)
+		if err := json.Unmarshal([]byte(data), die); err == nil {
+			defined[name] = die
+		}
+	}
+	return defined
+}
+
+// LookupCustomDie finds a die defined by a user, falling back to the dice
+// defined for the guild.
+func LookupCustomDie(ctx context.Context, uid, gid, name string) (*CustomDie, bool) {
+	if die, ok := GetCustomDice(ctx, uid, "")[name]; ok {
+		return die, true
+	}
+	if gid == "" {
+		return nil, false
+	}
+	die, ok := GetCustomDice(ctx, uid, gid)[name]
+	return die, ok
+}
+
+// withCustomDice returns a child context with the dice used by a roll already
+// looked up, so rolling them doesn't wait on Redis once the roll's evaluation
+// deadline has started.
+func withCustomDice(ctx context.Context, roll string) context.Context {
+	matches := customDiceRegexp.FindAllStringSubmatch(roll, -1)
+	if len(matches) == 0 {
+		return ctx
+	}
+	uid, _, gid := idsFromContext(ctx)
+	resolved := make(map[string]*CustomDie)
+	for _, match := range matches {
+		name := strings.ToLower(match[2])
+		if die, ok := LookupCustomDie(ctx, uid, gid, name); ok {
+			resolved[name] = die
+		}
+	}
+	return context.WithValue(ctx, KeyCustomDice, resolved)
+}
+
+// SetCustomDie saves a die for a user, or for a guild if gid is set.
+func SetCustomDie(ctx context.Context, uid, gid string, die *CustomDie) error {
+	if DiceGolem.Cache.Redis == nil {
+		return ErrNoRedisClient
+	}
+	key := customDiceKey(uid, gid)
+	defined := GetCustomDice(ctx, uid, gid)
+	if _, ok := defined[die.Name]; !ok && len(defined) >= maxCustomDice {
+		return ErrTooManyDieTypes
+	}
+	data, err := json.Marshal(die)
+	if err != nil {
+		return err
+	}
+	_, err = DiceGolem.Cache.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		defer DiceGolem.Cache.Remove(key)
+		pipe.HSet(ctx, key, die.Name, string(data))
+		pipe.Expire(ctx, key, DiceGolem.DataTTL)
+		return nil
+	})
+	return err
+}
+
+// DeleteCustomDie deletes a die for a user, or for a guild if gid is set,
+// returning whether it existed.
+func DeleteCustomDie(ctx context.Context, uid, gid, name string) (bool, error) {
+	if DiceGolem.Cache.Redis == nil {
+		return false, ErrNoRedisClient
+	}
+	key := customDiceKey(uid, gid)
+	defer DiceGolem.Cache.Remove(key)
+	n, err := DiceGolem.Cache.Redis.HDel(ctx, key, name).Result()
+	return n > 0, err
+}
+
+// rollCustomDice rolls user-defined dice like "3d{dwarf}". Numeric faces are
+// summed and symbol faces are tallied.
+func rollCustomDice(ctx context.Context, match []string) (*RolledNotation, error) {
+	count := 1
+	if match[1] != "" {
+		count, _ = strconv.Atoi(match[1])
+	}
+	if count > maxCustomPool {
+		return nil, ErrTooManyDice
+	}
+	name := strings.ToLower(match[2])
+	var (
+		die *CustomDie
+		ok  bool
+	)
+	if resolved, isResolved := ctx.Value(KeyCustomDice).(map[string]*CustomDie); isResolved {
+		die, ok = resolved[name]
+	} else {
+		uid, _, gid := idsFromContext(ctx)
+		die, ok = LookupCustomDie(ctx, uid, gid, name)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDie, match[2])
+	}
+
+	n := &RolledNotation{
+		Group:   &dice.RollerGroup{},
+		Symbols: make(map[string]int),
+	}
+	faces := make([]string, count)
+	for i := range faces {
+		face := die.Roll()
+		n.Value += face.Value
+		for _, symbol := range face.Symbols {
+			n.Symbols[symbol]++
+		}
+		faces[i] = faceString(face)
+	}
+	n.markdown = func(context.Context) string {
+		return "[" + strings.Join(faces, ", ") + "]"
+	}
+	return n, nil
+}
+
+// symbolString lists the symbols rolled on user-defined dice, most frequent
+// first, after the value of any numeric faces.
+func (n *RolledNotation) symbolString() string {
+	symbols := make([]string, 0, len(n.Symbols))
+	for symbol := range n.Symbols {
+		symbols = append(symbols, symbol)
+	}
+	slices.SortFunc(symbols, func(a, b string) int {
+		if c := n.Symbols[b] - n.Symbols[a]; c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	var parts []string
+	if n.Value != 0 {
+		parts = append(parts, strconv.FormatFloat(n.Value, 'f', -1, 64))
+	}
+	for _, symbol := range symbols {
+		parts = append(parts, fmt.Sprintf("%d %s", n.Symbols[symbol], symbol))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// markdownCustomDice lists defined dice and their faces.
+func markdownCustomDice(defined map[string]*CustomDie) string {
+	if len(defined) == 0 {
+		return "None"
+	}
+	names := make([]string, 0, len(defined))
+	for name := range defined {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var b strings.Builder
+	for _, name := range names {
+		faces := make([]string, len(defined[name].Faces))
+		for i, face := range defined[name].Faces {
+			faces[i] = faceString(face)
+		}
+		fmt.Fprintf(&b, "`d{%s}`: %s\n", name, strings.Join(faces, ", "))
+	}
+	return truncString(b.String(), 1024)
+}
+
+// InteractionDice manages user-defined dice.
+func InteractionDice(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	defer metrics.IncrCounter([]string{"interaction", "dice"}, 1)
+
+	if DiceGolem.Cache.Redis == nil {
+		MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+		return
+	}
+
+	uid := UserFromInteraction(i).ID
+	options := i.ApplicationCommandData().Options
+	subcommand := options[0].Name
+	options = options[0].Options
+
+	// dice can be defined for the whole server by its managers
+	var gid string
+	if opt := getOptionByName(options, "server"); opt != nil && opt.BoolValue() {
+		if i.GuildID == "" || i.Member == nil || i.Member.Permissions&discordgo.PermissionManageGuild == 0 {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse("Defining dice for the server requires the _Manage Server_ permission."))
+			return
+		}
+		gid = i.GuildID
+	}
+
+	var content string
+	switch subcommand {
+	case "define":
+		die, err := ParseCustomDie(mustGetOptionByName(options, "name").StringValue(), mustGetOptionByName(options, "faces").StringValue())
+		if err != nil {
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(err.Error()))
+			return
+		}
+		if err := SetCustomDie(ctx, uid, gid, die); err != nil {
+			if errors.Is(err, ErrTooManyDieTypes) {
+				MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(err.Error()))
+				return
+			}
+			logger.Error("error saving die", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		content = fmt.Sprintf("Defined `d{%s}` with %d faces. Roll it in expressions like `3d{%s}`!", die.Name, len(die.Faces), die.Name)
+	case "delete":
+		name := strings.ToLower(mustGetOptionByName(options, "name").StringValue())
+		ok, err := DeleteCustomDie(ctx, uid, gid, name)
+		if err != nil {
+			logger.Error("error deleting die", zap.Error(err))
+			MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(ErrUnexpectedError.Error()))
+			return
+		}
+		if !ok {
+			content = fmt.Sprintf("No die named `%s` was found.", name)
+		} else {
+			content = fmt.Sprintf("Deleted `d{%s}`.", name)
+		}
+	case "list":
+		content = "**Your dice**\n" + markdownCustomDice(GetCustomDice(ctx, uid, ""))
+		if i.GuildID != "" {
+			content += "\n**Server dice**\n" + markdownCustomDice(GetCustomDice(ctx, uid, i.GuildID))
+		}
+	default:
+		content = "Sorry, an invalid subcommand was received!"
+	}
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, newEphemeralResponse(content)); err != nil {
+		logger.Error("error sending response", zap.Error(err))
+	}
+}
+
+// SuggestCustomDice suggests the names of a user's and guild's defined dice.
+func SuggestCustomDice(ctx context.Context) {
+	s, i, _ := FromContext(ctx)
+	uid := UserFromInteraction(i).ID
+	input := getOptionByName(i.ApplicationCommandData().Options, "name").StringValue()
+
+	var names []string
+	for name := range GetCustomDice(ctx, uid, "") {
+		names = append(names, name)
+	}
+	if i.GuildID != "" {
+		for name := range GetCustomDice(ctx, uid, i.GuildID) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	choices := fuzzyFilterOptionChoices(input, ChoicesFromStrings(names))
+	if err := MeasureInteractionRespond(s.InteractionRespond, i, newChoicesResponse(trunc(DistinctChoices(choices), 25))); err != nil {
+		logger.Error("autocomplete", zap.Error(err), zap.String("user", uid))
+	}
+}
+
+// customDiceChoices creates autocomplete choices for rolling each of a user's
+// and guild's defined dice.
+func customDiceChoices(ctx context.Context, uid, gid string) []*discordgo.ApplicationCommandOptionChoice {
+	var notations []string
+	for name := range GetCustomDice(ctx, uid, "") {
+		notations = append(notations, "d{"+name+"}")
+	}
+	if gid != "" {
+		for name := range GetCustomDice(ctx, uid, gid) {
+			notations = append(notations, "d{"+name+"}")
+		}
+	}
+	slices.Sort(notations)
+	return ChoicesFromStrings(notations)
+}
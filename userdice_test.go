@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseCustomDie(t *testing.T) {
+	tests := []struct {
+		name      string
+		dieName   string
+		faces     string
+		wantName  string
+		wantFaces []Face
+		wantErr   error
+	}{
+		{name: "numeric", dieName: "dwarf", faces: "1,1,2,3,4,6", wantName: "dwarf", wantFaces: []Face{{Value: 1}, {Value: 1}, {Value: 2}, {Value: 3}, {Value: 4}, {Value: 6}}},
+		{name: "symbols", dieName: " Combat ", faces: "Hit, Hit ,Miss", wantName: "combat", wantFaces: []Face{{Symbols: []string{"Hit"}}, {Symbols: []string{"Hit"}}, {Symbols: []string{"Miss"}}}},
+		{name: "mixed", dieName: "odd-die_2", faces: "-1,0,Skull", wantName: "odd-die_2", wantFaces: []Face{{Value: -1}, {Value: 0}, {Symbols: []string{"Skull"}}}},
+		{name: "bad name", dieName: "2dwarf", faces: "1,2", wantErr: ErrInvalidDieName},
+		{name: "braces", dieName: "d{x}", faces: "1,2", wantErr: ErrInvalidDieName},
+		{name: "empty face", dieName: "dwarf", faces: "1,,2", wantErr: ErrInvalidDieFaces},
+		{name: "no faces", dieName: "dwarf", faces: "", wantErr: ErrInvalidDieFaces},
+		{name: "nan face", dieName: "dwarf", faces: "1,NaN", wantErr: ErrInvalidDieFaces},
+		{name: "inf face", dieName: "dwarf", faces: "-Inf,1", wantErr: ErrInvalidDieFaces},
+		{name: "long face", dieName: "dwarf", faces: "1,abcdefghijklmnopqrstuvwxyzabcdefg", wantErr: ErrInvalidDieFaces},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			die, err := ParseCustomDie(tt.dieName, tt.faces)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseCustomDie() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if die.Name != tt.wantName {
+				t.Errorf("ParseCustomDie() name = %v, want %v", die.Name, tt.wantName)
+			}
+			if !reflect.DeepEqual(die.Faces, tt.wantFaces) {
+				t.Errorf("ParseCustomDie() faces = %v, want %v", die.Faces, tt.wantFaces)
+			}
+		})
+	}
+}
+
+func Test_faceString(t *testing.T) {
+	tests := []struct {
+		face Face
+		want string
+	}{
+		{Face{Value: 3}, "3"},
+		{Face{Value: 0}, "0"},
+		{Face{Value: -1.5}, "-1.5"},
+		{Face{Symbols: []string{"Hit"}}, "Hit"},
+		{Face{Value: 2, Symbols: []string{"Hit"}}, "2 Hit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := faceString(tt.face); got != tt.want {
+				t.Errorf("faceString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRolledNotation_symbolString(t *testing.T) {
+	tests := []struct {
+		name     string
+		notation *RolledNotation
+		want     string
+	}{
+		{name: "symbols", notation: &RolledNotation{Symbols: map[string]int{"Miss": 1, "Hit": 3}}, want: "3 Hit, 1 Miss"},
+		{name: "ties", notation: &RolledNotation{Symbols: map[string]int{"b": 2, "a": 2}}, want: "2 a, 2 b"},
+		{name: "with value", notation: &RolledNotation{Value: 4, Symbols: map[string]int{"Skull": 1}}, want: "4, 1 Skull"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.notation.String(); got != tt.want {
+				t.Errorf("String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_customNotationRegexp(t *testing.T) {
+	tests := []struct {
+		notation string
+		want     []string
+	}{
+		{"3d{dwarf}", []string{"3d{dwarf}", "3", "dwarf"}},
+		{"d{Combat}+2", []string{"d{Combat}", "", "Combat"}},
+		{"3d6", nil},
+		{"3d{}", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.notation, func(t *testing.T) {
+			if got := testNotationRegexp("custom").FindStringSubmatch(tt.notation); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindStringSubmatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rollCustomDice_resolved(t *testing.T) {
+	ctx := context.WithValue(context.Background(), KeyCustomDice, map[string]*CustomDie{
+		"coin": {Name: "coin", Faces: []Face{{Value: 1, Symbols: []string{"Heads"}}}},
+	})
+	rolled, err := rollCustomDice(ctx, testNotationRegexp("custom").FindStringSubmatch("3d{Coin}"))
+	if err != nil {
+		t.Fatalf("rollCustomDice() error = %v", err)
+	}
+	if rolled.Value != 3 || rolled.Symbols["Heads"] != 3 {
+		t.Errorf("rollCustomDice() = %v, want 3 and 3 Heads", rolled)
+	}
+	if _, err := rollCustomDice(ctx, testNotationRegexp("custom").FindStringSubmatch("d{dwarf}")); !errors.Is(err, ErrUnknownDie) {
+		t.Errorf("rollCustomDice() error = %v, want %v", err, ErrUnknownDie)
+	}
+}
@@ -98,28 +98,10 @@ func deleteInteractionResponse(s *discordgo.Session, i *discordgo.Interaction, t
 	}
 }
 
-// trackRoll persists count information after a successful roll is made.
-func trackRollFromContext(ctx context.Context) {
-	// if no Redis cache, skip
-	if DiceGolem.Cache.Redis == nil {
-		return
-	}
-
-	defer recover()
-	s, i, m := FromContext(ctx)
-	if s == nil || (i == nil && m == nil) {
-		panic("context data missing")
-	}
-
-	logger.Debug("tracking roll")
-	metrics.IncrCounter([]string{"rolls"}, 1)
-
-	var (
-		uid string // user ID
-		cid string // channel ID
-		gid string // guild ID
-	)
-
+// idsFromContext returns the IDs of the user, channel and guild (if any) a
+// roll in the context was made by and in.
+func idsFromContext(ctx context.Context) (uid, cid, gid string) {
+	_, i, m := FromContext(ctx)
 	switch {
 	case m != nil:
 		if m.Member != nil && m.Member.User != nil {
@@ -136,12 +118,33 @@ func trackRollFromContext(ctx context.Context) {
 	default:
 		panic("unhandled roll type")
 	}
+	return
+}
+
+// trackRoll persists count information after a successful roll is made.
+func trackRollFromContext(ctx context.Context, groups []*dice.RollerGroup) {
+	// if no Redis cache, skip
+	if DiceGolem.Cache.Redis == nil {
+		return
+	}
+
+	defer recover()
+	s, i, m := FromContext(ctx)
+	if s == nil || (i == nil && m == nil) {
+		panic("context data missing")
+	}
+
+	logger.Debug("tracking roll")
+	metrics.IncrCounter([]string{"rolls"}, 1)
+
+	uid, cid, gid := idsFromContext(ctx)
 
 	defer metrics.MeasureSince([]string{"redis", "track_roll"}, time.Now())
 	_, err := DiceGolem.Cache.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
 		pipe.Incr(ctx, "rolls:total")
-		pipe.Incr(ctx, fmt.Sprintf("rolls:user:%s:total", uid))
-		pipe.SAdd(ctx, "rolls:users", uid)
+		pipe.Incr(ctx, fmt.Sprintf(KeyUserRollsTotalFmt, uid))
+		pipe.SAdd(ctx, KeyRollsUsers, uid)
+		trackDiceFromContext(ctx, pipe, uid, groups)
 		pipe.SAdd(ctx, "rolls:channels", cid)
 		pipe.Incr(ctx, fmt.Sprintf("rolls:guild:%s:chan:%s", gid, cid))
 		if gid != "" {
@@ -229,22 +232,8 @@ func guildCount(b *Bot) (guilds int, sharding []int, err error) {
 
 // MarkdownString converts a dice group into a Markdown-compatible text format.
 func MarkdownString(ctx context.Context, group *dice.RollerGroup) string {
-	var b strings.Builder
-	write := b.WriteString
-	for _, roller := range group.Group.Copy() {
-		val, _ := roller.Value(ctx)
-		sval := strconv.FormatFloat(val, 'f', -1, 64)
-		// TODO: check if critical
-		if roller.IsDropped(ctx) {
-			write("~~")
-			write(sval)
-			write("~~")
-		} else {
-			write(sval)
-		}
-		write(", ")
-	}
-	s := strings.TrimSuffix(b.String(), ", ")
+	// TODO: check if critical
+	s := markdownGroup(ctx, group, ExplodeNone, nil)
 	val, _ := group.Total(ctx)
 	// HACK: use string builder and include original notation
 	s = fmt.Sprintf("[%s] \u21D2 **%s**", s, strconv.FormatFloat(val, 'f', -1, 64))
@@ -264,6 +253,43 @@ func MarkdownDetails(ctx context.Context, groups []*dice.RollerGroup) string {
 	return b.String()
 }
 
+// plainDetails returns a plain text representation of the individual dice
+// values rolled for a response, with dropped dice in parentheses, ex.
+// "[20, (3)] [4, 5]". Exploded dice are shown as chains using the explosion
+// mode of their notation.
+func plainDetails(res *Response) string {
+	parts := make([]string, 0, len(res.Notations))
+	for _, n := range res.Notations {
+		parts = append(parts, plainGroup(n.Group, n.Explode))
+	}
+	if res.ExpressionResult != nil {
+		for _, group := range res.ExpressionResult.Dice {
+			parts = append(parts, plainGroup(group, ExplodeStandard))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// plainGroup returns a plain text representation of a dice group's values,
+// rendering any exploded chains with an explosion mode.
+func plainGroup(group *dice.RollerGroup, mode ExplodeMode) string {
+	ctx := context.Background()
+	values := make([]string, 0, len(group.Group))
+	for _, roller := range group.Group {
+		if chain, ok := roller.(*dice.RollerGroup); ok {
+			values = append(values, markdownChain(chain, mode, nil))
+			continue
+		}
+		val, _ := roller.Value(ctx)
+		sval := strconv.FormatFloat(val, 'f', -1, 64)
+		if roller.IsDropped(ctx) {
+			sval = "(" + sval + ")"
+		}
+		values = append(values, sval)
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
+
 // FIXME: this shouldn't be full responses themselves
 type RollLog struct {
 	Entries []*Response
@@ -303,11 +329,15 @@ func embedField(response *Response) *discordgo.MessageEmbedField {
 	field.Name = fmt.Sprintf("%s \u21D2 %s", response.Original, response.Result)
 	var b strings.Builder
 	write := b.WriteString
+	for _, notation := range response.Notations {
+		write(notation.Markdown(context.TODO()))
+		write("\n")
+	}
 	for _, group := range response.Dice {
 		write(MarkdownString(context.TODO(), group))
 		write("\n")
 	}
-	if len(response.Dice) == 0 {
+	if len(response.Groups()) == 0 {
 		write("```cs\n" + response.ExpressionResult.String() + "\n```")
 	}
 	field.Value = strings.TrimSpace(b.String())
@@ -349,6 +379,19 @@ func CommandMention(paths ...string) string {
 	return fmt.Sprintf("</%s:%s>", path, DiceGolem.SelfID)
 }
 
+// newComponentID builds a message component custom ID out of a handler name
+// and its arguments, ex. "data:delete".
+func newComponentID(name string, args ...string) string {
+	return strings.Join(append([]string{name}, args...), ":")
+}
+
+// parseComponentID splits a message component custom ID into the name of its
+// handler and any arguments.
+func parseComponentID(id string) (name string, args []string) {
+	parts := strings.Split(id, ":")
+	return parts[0], parts[1:]
+}
+
 // Ptr returns the pointer to the passed value.
 func Ptr[T any](v T) *T {
 	return &v
@@ -1,6 +1,12 @@
 package main
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+
+	"github.com/travis-g/dice"
+	"github.com/travis-g/dice/math"
+)
 
 func Test_truncString(t *testing.T) {
 	type args struct {
@@ -23,3 +29,60 @@ func Test_truncString(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseComponentID(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		wantName string
+		wantArgs []string
+	}{
+		{name: "bare", id: "data", wantName: "data", wantArgs: []string{}},
+		{name: "args", id: newComponentID("data", "delete"), wantName: "data", wantArgs: []string{"delete"}},
+		{name: "multiple", id: "history:page:2:d20", wantName: "history", wantArgs: []string{"page", "2", "d20"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, args := parseComponentID(tt.id)
+			if name != tt.wantName {
+				t.Errorf("parseComponentID() name = %v, want %v", name, tt.wantName)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("parseComponentID() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func Test_plainDetails(t *testing.T) {
+	chains := func(mode ExplodeMode) *RolledNotation {
+		return &RolledNotation{
+			Group:   &dice.RollerGroup{Group: dice.Group{testRollerGroup(6, 6, 6, 3), testRollerGroup(6, 2)}},
+			Explode: mode,
+		}
+	}
+	tests := []struct {
+		name string
+		res  *Response
+		want string
+	}{
+		{name: "empty", res: &Response{}, want: ""},
+		{name: "exploded", res: &Response{Notations: []*RolledNotation{chains(ExplodeStandard)}}, want: "[6→6→3, 2]"},
+		{name: "penetrated", res: &Response{Notations: []*RolledNotation{chains(ExplodePenetrate)}}, want: "[6→5→2, 2]"},
+		{
+			name: "notation and expression",
+			res: &Response{
+				Notations:        []*RolledNotation{chains(ExplodeCompound)},
+				ExpressionResult: &math.ExpressionResult{Dice: []*dice.RollerGroup{testRollerGroup(20, 14)}},
+			},
+			want: "[15 (6→6→3), 2] [14]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := plainDetails(tt.res); got != tt.want {
+				t.Errorf("plainDetails() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}